@@ -4,8 +4,14 @@ import (
 	"fmt"
 	"math/rand"
 	"time"
+
+	"1-basic/clock"
 )
 
+// clk is what fakeSearch sleeps on and main times Google with.
+// The test uses a clock.Fake to check that the searches add up.
+var clk = clock.Real
+
 type Result string
 
 type Search func(query string) Result
@@ -19,7 +25,7 @@ var (
 // We can simulate the search function, much as we simulated conversation before.
 func fakeSearch(kind string) Search {
 	return func(query string) Result {
-		time.Sleep(time.Duration(rand.Intn(100)) * time.Millisecond)
+		clk.Sleep(time.Duration(rand.Intn(100)) * time.Millisecond)
 		return Result(fmt.Sprintf("%s result for %q\n", kind, query))
 	}
 }
@@ -35,9 +41,9 @@ func Google(query string) (results []Result) {
 
 func main() {
 	rand.Seed(time.Now().UnixNano())
-	start := time.Now()
+	start := clk.Now()
 	results := Google("golang")
-	elapsed := time.Since(start)
+	elapsed := clk.Since(start)
 	fmt.Println(results)
 	fmt.Println(elapsed)
 }
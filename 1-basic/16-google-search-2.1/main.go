@@ -4,8 +4,14 @@ import (
 	"fmt"
 	"math/rand"
 	"time"
+
+	"1-basic/clock"
 )
 
+// clk times both fakeSearch and the 80ms timeout, so the test can put
+// a search past the deadline on a clock.Fake.
+var clk = clock.Real
+
 type Result string
 
 type Search func(query string) Result
@@ -19,7 +25,7 @@ var (
 // We can simulate the search function, much as we simulated conversation before.
 func fakeSearch(kind string) Search {
 	return func(query string) Result {
-		time.Sleep(time.Duration(rand.Intn(100)) * time.Millisecond)
+		clk.Sleep(time.Duration(rand.Intn(100)) * time.Millisecond)
 		return Result(fmt.Sprintf("%s result for %q\n", kind, query))
 	}
 }
@@ -40,7 +46,7 @@ func Google(query string) (results []Result) {
 
 	// A global timeout.
 	// It ignores the result from the server that taking response greater than 80ms.
-	timeout := time.After(80 * time.Millisecond)
+	timeout := clk.After(80 * time.Millisecond)
 	for i := 0; i < 3; i++ {
 		select {
 		case result := <-c:
@@ -55,9 +61,9 @@ func Google(query string) (results []Result) {
 
 func main() {
 	rand.Seed(time.Now().UnixNano())
-	start := time.Now()
+	start := clk.Now()
 	results := Google("golang")
-	elapsed := time.Since(start)
+	elapsed := clk.Since(start)
 	fmt.Println(results)
 	fmt.Println(elapsed)
 }
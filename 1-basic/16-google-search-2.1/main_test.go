@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"1-basic/clock"
+)
+
+// newFake makes clk a new clock.Fake for one test, so the counts of
+// pending waits start from zero. Searches left sleeping by a test stay
+// on its clock.
+func newFake() *clock.Fake {
+	fake := clock.NewFake(time.Unix(0, 0))
+	clk = fake
+	return fake
+}
+
+// fixed is a fakeSearch that always takes d.
+func fixed(kind string, d time.Duration) Search {
+	return func(query string) Result {
+		clk.Sleep(d)
+		return Result(fmt.Sprintf("%s result for %q\n", kind, query))
+	}
+}
+
+func TestGoogleInTime(t *testing.T) {
+	fake := newFake()
+	Web, Image, Video = fixed("web", 10*time.Millisecond), fixed("image", 20*time.Millisecond), fixed("video", 30*time.Millisecond)
+	start := clk.Now()
+	done := make(chan []Result)
+	go func() { done <- Google("golang") }()
+	fake.BlockUntil(4) // three searches and the timeout
+	fake.Advance(30 * time.Millisecond)
+	results := <-done
+	sort.Slice(results, func(i, j int) bool { return results[i] < results[j] }) // they race to arrive
+	want := []Result{"image result for \"golang\"\n", "video result for \"golang\"\n", "web result for \"golang\"\n"}
+	if fmt.Sprint(results) != fmt.Sprint(want) {
+		t.Errorf("Google = %q, want %q", results, want)
+	}
+	if elapsed := clk.Since(start); elapsed != 30*time.Millisecond {
+		t.Errorf("Google took %v, want the slowest search, 30ms", elapsed)
+	}
+}
+
+func TestGoogleTimeout(t *testing.T) {
+	fake := newFake()
+	Web, Image, Video = fixed("web", 100*time.Millisecond), fixed("image", 150*time.Millisecond), fixed("video", 200*time.Millisecond)
+	start := clk.Now()
+	done := make(chan []Result)
+	go func() { done <- Google("golang") }()
+	fake.BlockUntil(4)
+	fake.Advance(80 * time.Millisecond)
+	if results := <-done; len(results) != 0 {
+		t.Errorf("Google = %q, want nothing from searches slower than the timeout", results)
+	}
+	if elapsed := clk.Since(start); elapsed != 80*time.Millisecond {
+		t.Errorf("Google took %v, want the 80ms timeout", elapsed)
+	}
+}
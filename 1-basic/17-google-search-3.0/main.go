@@ -4,8 +4,14 @@ import (
 	"fmt"
 	"math/rand"
 	"time"
+
+	"1-basic/clock"
 )
 
+// clk times the replicas and the timeout; the test runs them on a
+// clock.Fake to check that the fastest replica wins.
+var clk = clock.Real
+
 type Result string
 
 type Search func(query string) Result
@@ -25,7 +31,7 @@ var (
 // We can simulate the search function, much as we simulated conversation before.
 func fakeSearch(kind string) Search {
 	return func(query string) Result {
-		time.Sleep(time.Duration(rand.Intn(100)) * time.Millisecond)
+		clk.Sleep(time.Duration(rand.Intn(100)) * time.Millisecond)
 		return Result(fmt.Sprintf("%s result for %q\n", kind, query))
 	}
 }
@@ -33,7 +39,9 @@ func fakeSearch(kind string) Search {
 // First To avoid discarding results from slow servers by replicating the servers.
 // Send requests to multiple replicas, and use the first response.
 func First(query string, replicas ...Search) Result {
-	c := make(chan Result)
+	// Buffered so the losing replicas can still send their result and exit,
+	// instead of blocking forever once we stop listening.
+	c := make(chan Result, len(replicas))
 	searchReplica := func(i int) {
 		c <- replicas[i](query)
 	}
@@ -58,7 +66,7 @@ func Google(query string) (results []Result) {
 
 	// A global timeout.
 	// It ignores the result from the server that taking response greater than 80ms.
-	timeout := time.After(80 * time.Millisecond)
+	timeout := clk.After(80 * time.Millisecond)
 	for i := 0; i < 3; i++ {
 		select {
 		case result := <-c:
@@ -73,9 +81,9 @@ func Google(query string) (results []Result) {
 
 func main() {
 	rand.Seed(time.Now().UnixNano())
-	start := time.Now()
+	start := clk.Now()
 	results := Google("golang")
-	elapsed := time.Since(start)
+	elapsed := clk.Since(start)
 	fmt.Println(results)
 	fmt.Println(elapsed)
 }
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"1-basic/clock"
+)
+
+// newFake makes clk a new clock.Fake for one test, so the counts of
+// pending waits start from zero. Searches left sleeping by a test stay
+// on its clock.
+func newFake() *clock.Fake {
+	fake := clock.NewFake(time.Unix(0, 0))
+	clk = fake
+	return fake
+}
+
+// fixed is a fakeSearch that always takes d.
+func fixed(kind string, d time.Duration) Search {
+	return func(query string) Result {
+		clk.Sleep(d)
+		return Result(fmt.Sprintf("%s result for %q\n", kind, query))
+	}
+}
+
+func TestGoogleReplicated(t *testing.T) {
+	fake := newFake()
+	ms := time.Millisecond
+	// One replica of each kind is fast enough; the others would time out.
+	Web1, Web2, Web3 = fixed("web 1", 10*ms), fixed("web 2", 200*ms), fixed("web 3", 300*ms)
+	Image1, Image2, Image3 = fixed("image 1", 200*ms), fixed("image 2", 20*ms), fixed("image 3", 300*ms)
+	Video1, Video2, Video3 = fixed("video 1", 200*ms), fixed("video 2", 300*ms), fixed("video 3", 30*ms)
+	start := clk.Now()
+	done := make(chan []Result)
+	go func() { done <- Google("golang") }()
+	fake.BlockUntil(10) // nine replicas and the timeout
+	fake.Advance(30 * time.Millisecond)
+	results := <-done
+	sort.Slice(results, func(i, j int) bool { return results[i] < results[j] }) // they race to arrive
+	want := []Result{"image 2 result for \"golang\"\n", "video 3 result for \"golang\"\n", "web 1 result for \"golang\"\n"}
+	if fmt.Sprint(results) != fmt.Sprint(want) {
+		t.Errorf("Google = %q, want %q", results, want)
+	}
+	if elapsed := clk.Since(start); elapsed != 30*time.Millisecond {
+		t.Errorf("Google took %v, want the slowest of the fastest replicas, 30ms", elapsed)
+	}
+}
@@ -4,8 +4,14 @@ import (
 	"fmt"
 	"math/rand"
 	"time"
+
+	"1-basic/clock"
 )
 
+// clk is the clock boring sleeps on; the test runs Joe and Ann on a
+// clock.Fake.
+var clk = clock.Real
+
 // The boring function returns a channel that lets us communicate with the boring service it provides.
 func boring(msg string) <-chan string { // Returns receive-only channel of strings.
 	c := make(chan string)
@@ -13,7 +19,7 @@ func boring(msg string) <-chan string { // Returns receive-only channel of strin
 		// Simulating an infinite sender which puts a message to the channel.
 		for i := 0; ; i++ {
 			c <- fmt.Sprintf("%s %d", msg, i)
-			time.Sleep(time.Duration(rand.Intn(1e3)) * time.Millisecond)
+			clk.Sleep(time.Duration(rand.Intn(1e3)) * time.Millisecond)
 		}
 	}()
 	return c // Returns the channel to the caller.
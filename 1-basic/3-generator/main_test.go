@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"1-basic/clock"
+)
+
+// fake is set once: Joe and Ann outlive each test, sleeping on it.
+var fake = clock.NewFake(time.Unix(0, 0))
+
+func init() { clk = fake }
+
+// recv receives from c, letting the talkers' pauses pass on the fake clock.
+func recv[T any](c <-chan T) T {
+	v, _ := clock.Recv(fake, c, time.Second)
+	return v
+}
+
+func TestBoring(t *testing.T) {
+	joe, ann := boring("Joe"), boring("Ann")
+	for i := 0; i < 3; i++ {
+		if got, want := recv(joe), fmt.Sprintf("Joe %d", i); got != want {
+			t.Errorf("joe said %q, want %q", got, want)
+		}
+		if got, want := recv(ann), fmt.Sprintf("Ann %d", i); got != want {
+			t.Errorf("ann said %q, want %q", got, want)
+		}
+	}
+}
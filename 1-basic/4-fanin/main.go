@@ -4,8 +4,14 @@ import (
 	"fmt"
 	"math/rand"
 	"time"
+
+	"1-basic/clock"
 )
 
+// clk paces the boring talkers, so the test can drive both fan-ins
+// with a clock.Fake.
+var clk = clock.Real
+
 // These programs make Joe and Ann count in lockstep.
 // We can instead use a fan-in function to let whoever is ready to talk.
 func fanIn(input1, input2 <-chan string) <-chan string {
@@ -59,7 +65,7 @@ func boring(msg string) <-chan string {
 		// An infinite loop to send messages to the channel.
 		for i := 0; ; i++ {
 			c <- fmt.Sprintf("%s %d", msg, i)
-			time.Sleep(time.Duration(rand.Intn(1e3)) * time.Millisecond)
+			clk.Sleep(time.Duration(rand.Intn(1e3)) * time.Millisecond)
 		}
 	}()
 	return c
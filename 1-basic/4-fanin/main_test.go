@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"1-basic/clock"
+)
+
+// fake is set once: Joe and Ann outlive each test, sleeping on it.
+var fake = clock.NewFake(time.Unix(0, 0))
+
+func init() { clk = fake }
+
+// recv receives from c, letting the talkers' pauses pass on the fake clock.
+func recv[T any](c <-chan T) T {
+	v, _ := clock.Recv(fake, c, time.Second)
+	return v
+}
+
+func TestFanIn(t *testing.T) {
+	for name, fanIn := range map[string]func(input1, input2 <-chan string) <-chan string{
+		"fanIn": fanIn,
+		"fanInSimple": func(input1, input2 <-chan string) <-chan string {
+			return fanInSimple(input1, input2)
+		},
+	} {
+		c := fanIn(boring("Joe"), boring("Ann"))
+		checkConversation(t, name, c, 10)
+	}
+}
+
+// checkConversation receives n messages from c and checks that each
+// talker's messages arrive in order.
+func checkConversation(t *testing.T, name string, c <-chan string, n int) {
+	t.Helper()
+	next := map[string]int{}
+	for i := 0; i < n; i++ {
+		msg := recv(c)
+		talker := strings.Fields(msg)[0]
+		if want := fmt.Sprintf("%s %d", talker, next[talker]); msg != want {
+			t.Errorf("%s: got %q, want %q", name, msg, want)
+		}
+		next[talker]++
+	}
+}
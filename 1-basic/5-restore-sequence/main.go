@@ -4,8 +4,14 @@ import (
 	"fmt"
 	"math/rand"
 	"time"
+
+	"1-basic/clock"
 )
 
+// clk paces the boring talkers; the test steps it with a clock.Fake
+// to check that they take turns.
+var clk = clock.Real
+
 // Send a channel on a channel, making goroutine wait its turn.
 // Receive all messages, then enable them again by sending on a private channel.
 
@@ -39,7 +45,7 @@ func boring(msg string) <-chan Message {
 				str:  fmt.Sprintf("%s %d", msg, i),
 				wait: waitForIt,
 			}
-			time.Sleep(time.Duration(rand.Intn(1e3)) * time.Millisecond)
+			clk.Sleep(time.Duration(rand.Intn(1e3)) * time.Millisecond)
 
 			// Everytime the goroutine sends message.
 			// This code waits until the value is received.
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"1-basic/clock"
+)
+
+// fake is set once: Joe and Ann outlive each test, sleeping on it.
+var fake = clock.NewFake(time.Unix(0, 0))
+
+func init() { clk = fake }
+
+// recv receives from c, letting the talkers' pauses pass on the fake clock.
+func recv[T any](c <-chan T) T {
+	v, _ := clock.Recv(fake, c, time.Second)
+	return v
+}
+
+func TestLockstep(t *testing.T) {
+	c := fanInSimple(boring("Joe"), boring("Ann"))
+	for round := 0; round < 5; round++ {
+		msg1, msg2 := recv(c), recv(c)
+		talker1, talker2 := strings.Fields(msg1.str)[0], strings.Fields(msg2.str)[0]
+		if talker1 == talker2 {
+			t.Fatalf("round %d: %s talked twice: %q, %q", round, talker1, msg1.str, msg2.str)
+		}
+		for _, msg := range []Message{msg1, msg2} {
+			talker := strings.Fields(msg.str)[0]
+			if want := fmt.Sprintf("%s %d", talker, round); msg.str != want {
+				t.Errorf("round %d: got %q, want %q", round, msg.str, want)
+			}
+			// The talkers sleep before they wait for it, so acknowledge
+			// in the background while recv advances the clock.
+			go func(wait chan bool) { wait <- true }(msg.wait)
+		}
+	}
+}
@@ -4,8 +4,14 @@ import (
 	"fmt"
 	"math/rand"
 	"time"
+
+	"1-basic/clock"
 )
 
+// clk is the clock boring sleeps on; the test steps through the
+// conversation on a clock.Fake.
+var clk = clock.Real
+
 // Rewrite our original fanIn function.
 // Only one goroutine is needed this time with select statement.
 // NEW
@@ -39,7 +45,7 @@ func boring(msg string) <-chan string {
 		// An infinite loop to send messages to the channel.
 		for i := 0; ; i++ {
 			c <- fmt.Sprintf("%s %d", msg, i)
-			time.Sleep(time.Duration(rand.Intn(1e3)) * time.Millisecond)
+			clk.Sleep(time.Duration(rand.Intn(1e3)) * time.Millisecond)
 		}
 	}()
 	return c
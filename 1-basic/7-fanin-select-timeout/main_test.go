@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"1-basic/clock"
+)
+
+// fake is set once: Joe and Ann outlive each test, sleeping on it.
+var fake = clock.NewFake(time.Unix(0, 0))
+
+func init() { clk = fake }
+
+// recv receives from c, letting the talkers' pauses pass on the fake clock.
+func recv[T any](c <-chan T) T {
+	v, _ := clock.Recv(fake, c, time.Second)
+	return v
+}
+
+func TestFanIn(t *testing.T) {
+	c := fanIn(boring("Joe"), boring("Ann"))
+	next := map[string]int{}
+	for i := 0; i < 10; i++ {
+		msg := recv(c)
+		talker := strings.Fields(msg)[0]
+		if want := fmt.Sprintf("%s %d", talker, next[talker]); msg != want {
+			t.Errorf("got %q, want %q", msg, want)
+		}
+		next[talker]++
+	}
+}
@@ -4,14 +4,20 @@ import (
 	"fmt"
 	"math/rand"
 	"time"
+
+	"1-basic/clock"
 )
 
+// clk drives both boring's pauses and the timeout on each message.
+// The test makes boring's pauses pass on a clock.Fake.
+var clk = clock.Real
+
 func boring(msg string) <-chan string {
 	c := make(chan string)
 	go func() {
 		for i := 0; i < 10; i++ {
 			c <- fmt.Sprintf("%s %d", msg, i)
-			time.Sleep(time.Duration(rand.Intn(1e3)) * time.Millisecond)
+			clk.Sleep(time.Duration(rand.Intn(1e3)) * time.Millisecond)
 		}
 	}()
 	return c
@@ -26,7 +32,7 @@ func main() {
 			fmt.Println(s)
 		// The time.After function returns a channel that blocks for the specified duration.
 		// After the interval, the channel delivers the current time, once.
-		case <-time.After(1 * time.Second): // We have a timeout defined for each message.
+		case <-clk.After(1 * time.Second): // We have a timeout defined for each message.
 			fmt.Println("You're too slow.")
 			return
 		}
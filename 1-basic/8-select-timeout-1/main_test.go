@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"1-basic/clock"
+)
+
+// fake is set once: Joe outlives each test, sleeping on it.
+var fake = clock.NewFake(time.Unix(0, 0))
+
+func init() { clk = fake }
+
+// recv receives from c, letting the talkers' pauses pass on the fake clock.
+func recv[T any](c <-chan T) T {
+	v, _ := clock.Recv(fake, c, time.Second)
+	return v
+}
+
+func TestBoringStops(t *testing.T) {
+	c := boring("Joe")
+	for i := 0; i < 10; i++ {
+		if got, want := recv(c), fmt.Sprintf("Joe %d", i); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+	fake.Advance(time.Second) // Joe's last pause
+	select {
+	case msg := <-c:
+		t.Fatalf("Joe said %q after his 10 messages", msg)
+	default:
+	}
+}
+
+func TestMainTimesOut(t *testing.T) {
+	done := make(chan struct{})
+	go func() {
+		main()
+		close(done)
+	}()
+	// Joe stops after 10 messages; main gives up a second later.
+	for {
+		select {
+		case <-done:
+			return
+		case <-fake.Blocked(1):
+			fake.Advance(100 * time.Millisecond)
+		}
+	}
+}
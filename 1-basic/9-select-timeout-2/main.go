@@ -4,14 +4,20 @@ import (
 	"fmt"
 	"math/rand"
 	"time"
+
+	"1-basic/clock"
 )
 
+// clk drives boring's pauses and the timeout of the whole conversation,
+// which the test reaches at once by advancing a clock.Fake.
+var clk = clock.Real
+
 func boring(msg string) <-chan string {
 	c := make(chan string)
 	go func() {
 		for i := 0; ; i++ { // An infinite loop
 			c <- fmt.Sprintf("%s %d", msg, i)
-			time.Sleep(time.Duration(rand.Intn(1e3)) * time.Millisecond)
+			clk.Sleep(time.Duration(rand.Intn(1e3)) * time.Millisecond)
 		}
 	}()
 	return c
@@ -20,7 +26,7 @@ func boring(msg string) <-chan string {
 func main() {
 	c := boring("Joe")
 	// Create the timer once, outside the loop, to time out the entire conversation.
-	timeout := time.After(5 * time.Second)
+	timeout := clk.After(5 * time.Second)
 	for {
 		// Timeout for whole conversation using select
 		select {
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"1-basic/clock"
+)
+
+// fake is set once: Joe outlives the test, sleeping on it.
+var fake = clock.NewFake(time.Unix(0, 0))
+
+func init() { clk = fake }
+
+func TestConversationTimeout(t *testing.T) {
+	start := fake.Now()
+	done := make(chan struct{})
+	go func() {
+		main()
+		close(done)
+	}()
+	// Let Joe's pauses pass until the conversation times out.
+	for {
+		select {
+		case <-done:
+			if talked := fake.Since(start); talked < 5*time.Second {
+				t.Errorf("main gave up after %v, want the 5s timeout", talked)
+			}
+			return
+		case <-fake.Blocked(1):
+			fake.Advance(time.Second)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+// Package clock abstracts the functions of package time that wait,
+// so that time-based patterns can be driven by a fake clock in tests.
+//
+// Code takes a Clock where it would call time.Now, time.After, time.Sleep,
+// time.NewTimer or time.AfterFunc, and uses Real in production.
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and waits for it.
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	After(d time.Duration) <-chan time.Time
+	Sleep(d time.Duration)
+	AfterFunc(d time.Duration, f func()) Timer
+
+	// At returns a channel that receives the time once it is t, and the
+	// Timer to stop the wait with when it is no longer wanted. Unlike
+	// After(t.Sub(Now())), the clock cannot move between reading it and
+	// starting to wait.
+	At(t time.Time) (<-chan time.Time, Timer)
+}
+
+// Timer is a pending wait started by At or AfterFunc.
+type Timer interface {
+	// Stop prevents the wait from firing, reporting false if it already did.
+	Stop() bool
+}
+
+// Real is the Clock of package time.
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) Since(t time.Time) time.Duration        { return time.Since(t) }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+func (realClock) At(t time.Time) (<-chan time.Time, Timer) {
+	timer := time.NewTimer(time.Until(t))
+	return timer.C, timer
+}
+
+// Fake is a Clock that only moves when Advance is called.
+// Waits that come due fire in order of their deadlines.
+//
+// A wait is pending until it fires or its Timer is stopped. A channel
+// from After that nobody receives from any more stays pending until it
+// comes due, so code that may give up on a wait should start it with At
+// and stop it, to keep Pending and BlockUntil meaningful.
+type Fake struct {
+	mu       sync.Mutex
+	now      time.Time
+	waiters  []*waiter  // sorted by when
+	watchers []*watcher // of Blocked, waiting for enough waiters
+}
+
+type waiter struct {
+	when time.Time
+	c    chan time.Time // for After and Sleep
+	f    func()         // for AfterFunc
+}
+
+// watcher is a call of Blocked waiting for n waiters.
+type watcher struct {
+	n int
+	c chan struct{} // closed once there are
+}
+
+// NewFake returns a Fake clock reading now.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	c := make(chan time.Time, 1) // so Advance never blocks on a reader
+	f.add(&waiter{c: c}, d)
+	return c
+}
+
+func (f *Fake) Sleep(d time.Duration) {
+	<-f.After(d)
+}
+
+func (f *Fake) AfterFunc(d time.Duration, fn func()) Timer {
+	w := &waiter{f: fn}
+	f.add(w, d)
+	return &fakeTimer{clock: f, w: w}
+}
+
+func (f *Fake) At(t time.Time) (<-chan time.Time, Timer) {
+	c := make(chan time.Time, 1)
+	w := &waiter{c: c}
+	f.addAt(w, t)
+	return c, &fakeTimer{clock: f, w: w}
+}
+
+// add schedules w to fire d from now, or right away if d <= 0.
+func (f *Fake) add(w *waiter, d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.schedule(w, f.now.Add(d))
+}
+
+// addAt schedules w to fire at t, or right away if t is not after now.
+func (f *Fake) addAt(w *waiter, t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.schedule(w, t)
+}
+
+// schedule adds w to the waiters, to fire at when. f.mu must be held.
+func (f *Fake) schedule(w *waiter, when time.Time) {
+	w.when = when
+	if !when.After(f.now) {
+		w.fire(f.now)
+		return
+	}
+	i := sort.Search(len(f.waiters), func(i int) bool {
+		return f.waiters[i].when.After(w.when)
+	})
+	f.waiters = append(f.waiters, nil)
+	copy(f.waiters[i+1:], f.waiters[i:])
+	f.waiters[i] = w
+	f.notify()
+}
+
+// remove unschedules w, reporting whether it was still pending.
+func (f *Fake) remove(w *waiter) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, x := range f.waiters {
+		if x == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Advance moves the clock forward by d, firing every wait that comes due.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	end := f.now.Add(d)
+	for len(f.waiters) > 0 && !f.waiters[0].when.After(end) {
+		w := f.waiters[0]
+		f.waiters = f.waiters[1:]
+		f.now = w.when // a waiter firing sees its own deadline
+		w.fire(f.now)
+	}
+	f.now = end
+}
+
+// BlockUntil waits until at least n waits are pending on the clock.
+// Tests call it before Advance to be sure the code under test is waiting.
+func (f *Fake) BlockUntil(n int) {
+	<-f.Blocked(n)
+}
+
+// Blocked returns a channel that is closed once at least n waits are
+// pending on the clock. Unlike BlockUntil, it can be selected on along
+// with the output of code that may or may not wait before it sends.
+func (f *Fake) Blocked(n int) <-chan struct{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w := &watcher{n: n, c: make(chan struct{})}
+	f.watchers = append(f.watchers, w)
+	f.notify()
+	return w.c
+}
+
+// notify releases the watchers that have enough waiters. f.mu must be held.
+func (f *Fake) notify() {
+	waiting := f.watchers[:0]
+	for _, w := range f.watchers {
+		if len(f.waiters) >= w.n {
+			close(w.c)
+		} else {
+			waiting = append(waiting, w)
+		}
+	}
+	f.watchers = waiting
+}
+
+// Pending returns the number of waits pending on the clock.
+func (f *Fake) Pending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+func (w *waiter) fire(now time.Time) {
+	if w.f != nil {
+		go w.f() // like time.AfterFunc, in its own goroutine
+		return
+	}
+	w.c <- now
+}
+
+type fakeTimer struct {
+	clock *Fake
+	w     *waiter
+}
+
+func (t *fakeTimer) Stop() bool {
+	return t.clock.remove(t.w)
+}
+
+// Recv receives from c like <-c, advancing f by step whenever a wait is
+// pending and nothing has arrived yet. Tests use it for code that sleeps
+// on f for an unknown time before it sends.
+func Recv[T any](f *Fake, c <-chan T, step time.Duration) (T, bool) {
+	for {
+		select {
+		case v, ok := <-c:
+			return v, ok
+		case <-f.Blocked(1):
+			f.Advance(step)
+		}
+	}
+}
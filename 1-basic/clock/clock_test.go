@@ -0,0 +1,136 @@
+package clock_test
+
+import (
+	"testing"
+	"time"
+
+	"1-basic/clock"
+)
+
+var start = time.Unix(0, 0)
+
+func TestAdvanceFiresInOrder(t *testing.T) {
+	f := clock.NewFake(start)
+	late, early := f.After(2*time.Second), f.After(time.Second)
+	if n := f.Pending(); n != 2 {
+		t.Fatalf("Pending = %d, want 2", n)
+	}
+	f.Advance(500 * time.Millisecond)
+	select {
+	case <-early:
+		t.Fatal("fired before its deadline")
+	default:
+	}
+	f.Advance(2 * time.Second)
+	// Each wait sees its own deadline, then the clock reads the end.
+	if got := <-early; !got.Equal(start.Add(time.Second)) {
+		t.Errorf("early fired at %v, want %v", got, start.Add(time.Second))
+	}
+	if got := <-late; !got.Equal(start.Add(2 * time.Second)) {
+		t.Errorf("late fired at %v, want %v", got, start.Add(2*time.Second))
+	}
+	if now := f.Now(); !now.Equal(start.Add(2500 * time.Millisecond)) {
+		t.Errorf("Now = %v, want %v", now, start.Add(2500*time.Millisecond))
+	}
+	if n := f.Pending(); n != 0 {
+		t.Errorf("Pending = %d after firing everything, want 0", n)
+	}
+}
+
+func TestNonPositiveWaitsFireAtOnce(t *testing.T) {
+	f := clock.NewFake(start)
+	f.Sleep(0)
+	<-f.After(-time.Second)
+	c, _ := f.At(start)
+	<-c
+	if n := f.Pending(); n != 0 {
+		t.Errorf("Pending = %d, want 0", n)
+	}
+}
+
+func TestAtStop(t *testing.T) {
+	f := clock.NewFake(start)
+	c, timer := f.At(start.Add(time.Second))
+	if n := f.Pending(); n != 1 {
+		t.Fatalf("Pending = %d, want 1", n)
+	}
+	if !timer.Stop() {
+		t.Error("Stop of a pending wait = false, want true")
+	}
+	if n := f.Pending(); n != 0 {
+		t.Errorf("Pending = %d after Stop, want 0", n)
+	}
+	f.Advance(time.Hour)
+	select {
+	case <-c:
+		t.Error("a stopped wait fired")
+	default:
+	}
+	if timer.Stop() {
+		t.Error("second Stop = true, want false")
+	}
+}
+
+func TestAtIgnoresAdvanceBeforeIt(t *testing.T) {
+	f := clock.NewFake(start)
+	deadline := start.Add(time.Second)
+	f.Advance(600 * time.Millisecond) // between reading the clock and waiting
+	c, _ := f.At(deadline)
+	f.Advance(400 * time.Millisecond)
+	if got := <-c; !got.Equal(deadline) {
+		t.Errorf("fired at %v, want %v", got, deadline)
+	}
+}
+
+func TestAfterFunc(t *testing.T) {
+	f := clock.NewFake(start)
+	called := make(chan struct{})
+	f.AfterFunc(time.Second, func() { close(called) })
+	stopped := f.AfterFunc(time.Second, func() { t.Error("a stopped AfterFunc ran") })
+	if !stopped.Stop() {
+		t.Error("Stop = false, want true")
+	}
+	f.Advance(time.Second)
+	<-called
+}
+
+func TestBlocked(t *testing.T) {
+	f := clock.NewFake(start)
+	blocked := f.Blocked(2)
+	go f.Sleep(time.Second)
+	go f.Sleep(time.Second)
+	<-blocked
+	select {
+	case <-f.Blocked(3):
+		t.Error("Blocked(3) closed with 2 waits pending")
+	default:
+	}
+	f.BlockUntil(2) // already true
+	f.Advance(time.Second)
+	if n := f.Pending(); n != 0 {
+		t.Errorf("Pending = %d, want 0", n)
+	}
+}
+
+func TestRecv(t *testing.T) {
+	f := clock.NewFake(start)
+	c := make(chan int)
+	go func() {
+		for i := 0; i < 3; i++ {
+			f.Sleep(time.Duration(i) * time.Second)
+			c <- i
+		}
+		close(c)
+	}()
+	for i := 0; i < 3; i++ {
+		if v, ok := clock.Recv(f, c, time.Second); !ok || v != i {
+			t.Fatalf("Recv = %d, %v, want %d, true", v, ok, i)
+		}
+	}
+	if _, ok := clock.Recv(f, c, time.Second); ok {
+		t.Error("Recv of a closed channel = ok")
+	}
+	if now := f.Now(); !now.Equal(start.Add(3 * time.Second)) {
+		t.Errorf("Now = %v, want %v: one step per second slept", now, start.Add(3*time.Second))
+	}
+}
@@ -0,0 +1,165 @@
+package fanin
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// ErrClosed is returned when adding to or removing from a Dynamic that was closed.
+var ErrClosed = errors.New("fanin: closed")
+
+// ErrNotMerged is returned when removing an input that is not merged.
+var ErrNotMerged = errors.New("fanin: input not merged")
+
+// Dynamic merges a changing set of inputs into one channel using a single
+// goroutine, however many inputs there are, where FanIn starts one per
+// input. It waits on all of them at once with reflect.Select, like the
+// select of the fanIn in 7-fanin-select-timeout, which only fits two.
+//
+// Selecting over every input costs time proportional to their number for
+// each value, so Dynamic trades throughput for goroutines; BenchmarkDynamic
+// measures how much.
+//
+// A closed input is dropped. The output stays open for inputs added
+// later, until Close is called or the context is done.
+type Dynamic[T any] struct {
+	c        chan T
+	requests chan request[T]
+	quit     chan struct{} // closed by Close
+	done     chan struct{} // closed when the goroutine has returned
+	stop     sync.Once
+}
+
+// request asks the goroutine to add or remove an input.
+type request[T any] struct {
+	input  <-chan T
+	remove bool
+	reply  chan error
+}
+
+// Indexes of the fixed cases of the select in loop; the inputs follow.
+const (
+	requestCase = iota
+	ctxCase
+	quitCase
+	sendCase
+	inputCases
+)
+
+// NewDynamic returns a Dynamic merging inputs, which stops when ctx is done.
+func NewDynamic[T any](ctx context.Context, inputs ...<-chan T) *Dynamic[T] {
+	d := &Dynamic[T]{
+		c:        make(chan T),
+		requests: make(chan request[T]),
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go d.loop(ctx, inputs)
+	return d
+}
+
+// C returns the channel that receives the values of the inputs.
+// It is closed once d has stopped.
+func (d *Dynamic[T]) C() <-chan T {
+	return d.c
+}
+
+// Add starts merging input.
+func (d *Dynamic[T]) Add(input <-chan T) error {
+	return d.request(request[T]{input: input})
+}
+
+// Remove stops merging input, without closing it. A value already taken
+// from input may still be delivered.
+func (d *Dynamic[T]) Remove(input <-chan T) error {
+	return d.request(request[T]{input: input, remove: true})
+}
+
+func (d *Dynamic[T]) request(r request[T]) error {
+	r.reply = make(chan error, 1)
+	select {
+	case d.requests <- r:
+		return <-r.reply
+	case <-d.done:
+		return ErrClosed
+	}
+}
+
+// Close stops merging and closes the output channel. A value taken from
+// an input but not yet received is lost. Close may be called more than once.
+func (d *Dynamic[T]) Close() {
+	d.stop.Do(func() { close(d.quit) })
+	<-d.done
+}
+
+func (d *Dynamic[T]) loop(ctx context.Context, inputs []<-chan T) {
+	defer close(d.done)
+	defer close(d.c)
+
+	cases := make([]reflect.SelectCase, inputCases, inputCases+len(inputs))
+	cases[requestCase] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(d.requests)}
+	cases[ctxCase] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}
+	cases[quitCase] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(d.quit)}
+	cases[sendCase] = reflect.SelectCase{Dir: reflect.SelectSend} // enabled while a value is pending
+	for _, input := range inputs {
+		cases = append(cases, recvCase(input))
+	}
+	out := reflect.ValueOf(d.c)
+	pending := false
+
+	for {
+		// While a value is pending, leave the inputs out of the select,
+		// like setting their channels to nil.
+		active := cases
+		if pending {
+			active = cases[:inputCases]
+		}
+		i, v, ok := reflect.Select(active)
+		switch i {
+		case requestCase:
+			r := v.Interface().(request[T])
+			r.reply <- d.apply(&cases, r)
+		case ctxCase, quitCase:
+			return
+		case sendCase:
+			cases[sendCase].Chan, cases[sendCase].Send = reflect.Value{}, reflect.Value{}
+			pending = false
+		default:
+			if !ok {
+				removeCase(&cases, i)
+				break
+			}
+			cases[sendCase].Chan, cases[sendCase].Send = out, v
+			pending = true
+		}
+	}
+}
+
+// apply adds or removes the input of r.
+func (d *Dynamic[T]) apply(cases *[]reflect.SelectCase, r request[T]) error {
+	if !r.remove {
+		*cases = append(*cases, recvCase(r.input))
+		return nil
+	}
+	for i := inputCases; i < len(*cases); i++ {
+		if (*cases)[i].Chan.Interface().(<-chan T) == r.input {
+			removeCase(cases, i)
+			return nil
+		}
+	}
+	return ErrNotMerged
+}
+
+func recvCase[T any](input <-chan T) reflect.SelectCase {
+	return reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(input)}
+}
+
+// removeCase removes the i-th case, moving the last case into its place.
+func removeCase(cases *[]reflect.SelectCase, i int) {
+	last := len(*cases) - 1
+	(*cases)[i] = (*cases)[last]
+	(*cases)[last] = reflect.SelectCase{}
+	*cases = (*cases)[:last]
+}
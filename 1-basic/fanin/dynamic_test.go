@@ -0,0 +1,160 @@
+package fanin_test
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"testing"
+	"time"
+
+	"1-basic/fanin"
+)
+
+// send returns a channel that receives values and is then closed.
+func send(values ...int) <-chan int {
+	c := make(chan int)
+	go func() {
+		defer close(c)
+		for _, v := range values {
+			c <- v
+		}
+	}()
+	return c
+}
+
+// recvTimeout receives from c, failing t if nothing arrives for a second.
+func recvTimeout(t *testing.T, c <-chan int) (int, bool) {
+	t.Helper()
+	select {
+	case v, ok := <-c:
+		return v, ok
+	case <-time.After(time.Second):
+		t.Fatal("nothing received for a second")
+		return 0, false
+	}
+}
+
+func TestDynamicAddRemove(t *testing.T) {
+	d := fanin.NewDynamic(context.Background(), send(1, 2))
+	defer d.Close()
+	if err := d.Add(send(3)); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	var got []int
+	for len(got) < 3 {
+		v, _ := recvTimeout(t, d.C())
+		got = append(got, v)
+	}
+	sort.Ints(got)
+	if fmt.Sprint(got) != "[1 2 3]" {
+		t.Errorf("received %v, want [1 2 3]", got)
+	}
+
+	// A removed input is left open and no longer read.
+	quiet := make(chan int)
+	if err := d.Add(quiet); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := d.Remove(quiet); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	select {
+	case quiet <- 4:
+		t.Error("a removed input was read")
+	case <-time.After(10 * time.Millisecond):
+	}
+	if err := d.Remove(quiet); err != fanin.ErrNotMerged {
+		t.Errorf("Remove of a removed input = %v, want ErrNotMerged", err)
+	}
+	// The output stays open with no inputs left, for inputs added later.
+	if err := d.Add(send(5)); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if v, _ := recvTimeout(t, d.C()); v != 5 {
+		t.Errorf("received %d, want 5", v)
+	}
+}
+
+func TestDynamicClose(t *testing.T) {
+	d := fanin.NewDynamic[int](context.Background())
+	d.Close()
+	d.Close() // again
+	if _, ok := recvTimeout(t, d.C()); ok {
+		t.Error("output open after Close")
+	}
+	if err := d.Add(send(1)); err != fanin.ErrClosed {
+		t.Errorf("Add after Close = %v, want ErrClosed", err)
+	}
+	if err := d.Remove(send(1)); err != fanin.ErrClosed {
+		t.Errorf("Remove after Close = %v, want ErrClosed", err)
+	}
+}
+
+func TestDynamicContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := fanin.NewDynamic(ctx, make(chan int))
+	cancel()
+	if _, ok := recvTimeout(t, d.C()); ok {
+		t.Error("output open after ctx is done")
+	}
+	if err := d.Add(send(1)); err != fanin.ErrClosed {
+		t.Errorf("Add after ctx is done = %v, want ErrClosed", err)
+	}
+}
+
+// merger merges inputs, returning the merged channel and a function that
+// stops the merge.
+type merger func(ctx context.Context, inputs []<-chan int) (<-chan int, func())
+
+func BenchmarkFanIn(b *testing.B) {
+	benchMerge(b, func(ctx context.Context, inputs []<-chan int) (<-chan int, func()) {
+		ctx, cancel := context.WithCancel(ctx)
+		return fanin.FanIn(ctx, inputs...), cancel
+	})
+}
+
+func BenchmarkDynamic(b *testing.B) {
+	benchMerge(b, func(ctx context.Context, inputs []<-chan int) (<-chan int, func()) {
+		d := fanin.NewDynamic(ctx, inputs...)
+		return d.C(), d.Close
+	})
+}
+
+// benchMerge delivers b.N messages through merge from 1 to 1000 producers,
+// spread evenly over them, and reports how many goroutines merge started.
+func benchMerge(b *testing.B, merge merger) {
+	for _, n := range []int{1, 10, 100, 1000} {
+		b.Run(fmt.Sprintf("inputs=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			start := make(chan struct{}) // holds the producers until merge is counted
+			chans := make([]<-chan int, n)
+			for i := range chans {
+				c := make(chan int)
+				chans[i] = c
+				count := b.N / n
+				if i < b.N%n {
+					count++
+				}
+				go func(c chan<- int, count int) {
+					defer close(c)
+					<-start
+					for j := 0; j < count; j++ {
+						c <- j
+					}
+				}(c, count)
+			}
+			b.ResetTimer()
+			before := runtime.NumGoroutine()
+			c, stop := merge(context.Background(), chans)
+			goroutines := runtime.NumGoroutine() - before
+			close(start)
+			for i := 0; i < b.N; i++ {
+				<-c
+			}
+			b.StopTimer()
+			stop()
+			b.ReportMetric(float64(goroutines), "goroutines")
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package fanin
+
+import (
+	"context"
+	"reflect"
+)
+
+// Weight is an input of Weighted with the number of values it may deliver
+// in a row when its turn comes.
+type Weight[T any] struct {
+	Input  <-chan T
+	Weight int // less than 1 counts as 1
+}
+
+// RoundRobin is like FanIn but fair: when several inputs have values
+// ready, it takes one from each in turn, where the pseudo-random choice of
+// select lets a chatty input crowd out the others over short stretches.
+// An input with nothing ready loses its turn; RoundRobin waits only when
+// no input is ready.
+func RoundRobin[T any](ctx context.Context, inputs ...<-chan T) <-chan T {
+	weights := make([]Weight[T], len(inputs))
+	for i, input := range inputs {
+		weights[i] = Weight[T]{input, 1}
+	}
+	return Weighted(ctx, weights...)
+}
+
+// Weighted is RoundRobin giving each input as many values in a row as its
+// Weight, so that an input of weight 3 delivers three values for each one
+// of an input of weight 1 while both have values ready.
+func Weighted[T any](ctx context.Context, inputs ...Weight[T]) <-chan T {
+	c := make(chan T)
+	go func() {
+		defer close(c)
+		chans := make([]<-chan T, len(inputs))
+		weights := make([]int, len(inputs))
+		for i, in := range inputs {
+			chans[i], weights[i] = in.Input, in.Weight
+			if weights[i] < 1 {
+				weights[i] = 1
+			}
+		}
+		turn, credit := 0, 0
+		if len(weights) > 0 {
+			credit = weights[0]
+		}
+		for len(chans) > 0 {
+			i, v, ok, stop := next(ctx, chans, turn)
+			if stop {
+				return
+			}
+			if !ok {
+				chans = append(chans[:i], chans[i+1:]...)
+				weights = append(weights[:i], weights[i+1:]...)
+				switch {
+				case i < turn:
+					turn--
+				case i == turn:
+					if turn == len(chans) {
+						turn = 0
+					}
+					if len(chans) > 0 {
+						credit = weights[turn]
+					}
+				}
+				continue
+			}
+			if !send(ctx, c, v) {
+				return
+			}
+			if i != turn { // turn passed over inputs with nothing ready
+				turn, credit = i, weights[i]
+			}
+			if credit--; credit == 0 {
+				turn = (turn + 1) % len(chans)
+				credit = weights[turn]
+			}
+		}
+	}()
+	return c
+}
+
+// Priority is like FanIn but always takes from the first input that has a
+// value ready, so a later input only gets through while every earlier one
+// has nothing to send.
+func Priority[T any](ctx context.Context, inputs ...<-chan T) <-chan T {
+	c := make(chan T)
+	go func() {
+		defer close(c)
+		ins := append([]<-chan T(nil), inputs...)
+		for len(ins) > 0 {
+			i, v, ok, stop := next(ctx, ins, 0)
+			if stop {
+				return
+			}
+			if !ok {
+				ins = append(ins[:i], ins[i+1:]...)
+				continue
+			}
+			if !send(ctx, c, v) {
+				return
+			}
+		}
+	}()
+	return c
+}
+
+// next receives from the first of inputs, starting at from and wrapping
+// around, that has a value ready, or else waits for any of them.
+// It reports which input it received from, and ok false if that input
+// is closed. stop is true if ctx is done.
+func next[T any](ctx context.Context, inputs []<-chan T, from int) (i int, v T, ok, stop bool) {
+	if ctx.Err() != nil {
+		return 0, v, false, true
+	}
+	for k := range inputs {
+		i := (from + k) % len(inputs)
+		select {
+		case v, ok := <-inputs[i]:
+			return i, v, ok, false
+		default:
+		}
+	}
+	cases := make([]reflect.SelectCase, len(inputs)+1)
+	for i, input := range inputs {
+		cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(input)}
+	}
+	cases[len(inputs)] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}
+	i, rv, ok := reflect.Select(cases)
+	if i == len(inputs) {
+		return 0, v, false, true
+	}
+	if ok {
+		v, _ = rv.Interface().(T) // a nil interface value stays the zero T
+	}
+	return i, v, ok, false
+}
+
+// send sends v on c unless ctx is done first, reporting whether it did.
+func send[T any](ctx context.Context, c chan<- T, v T) bool {
+	select {
+	case c <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
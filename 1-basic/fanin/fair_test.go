@@ -0,0 +1,56 @@
+package fanin_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"1-basic/fanin"
+)
+
+// filled returns a closed channel holding n copies of msg, so that it
+// always has a value ready until it drains.
+func filled(msg string, n int) <-chan string {
+	c := make(chan string, n)
+	for i := 0; i < n; i++ {
+		c <- msg
+	}
+	close(c)
+	return c
+}
+
+// initials receives everything from c and returns the first letter of each.
+func initials(c <-chan string) string {
+	var b strings.Builder
+	for msg := range c {
+		b.WriteByte(msg[0])
+	}
+	return b.String()
+}
+
+func TestRoundRobin(t *testing.T) {
+	c := fanin.RoundRobin(context.Background(), filled("Joe", 4), filled("Ann", 2), filled("Bob", 3))
+	// Each in turn; a drained input drops out of the rotation.
+	if got, want := initials(c), "JABJABJBJ"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestWeighted(t *testing.T) {
+	c := fanin.Weighted(context.Background(),
+		fanin.Weight[string]{Input: filled("Joe", 8), Weight: 3},
+		fanin.Weight[string]{Input: filled("Ann", 4), Weight: 1},
+		fanin.Weight[string]{Input: filled("Bob", 1), Weight: 0}, // counts as 1
+	)
+	if got, want := initials(c), "JJJABJJJAJJAA"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestPriority(t *testing.T) {
+	c := fanin.Priority(context.Background(), filled("Joe", 3), filled("Ann", 3))
+	// Ann waits until Joe has nothing more to say.
+	if got, want := initials(c), "JJJAAA"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,60 @@
+// Package fanin merges channels into one, like the fanIn functions of the
+// examples, for any element type and without their loose ends: a closed
+// input is dropped instead of spinning on zero values, the output is
+// closed once every input has drained, and a context stops it early.
+//
+//	ctx, cancel := context.WithCancel(context.Background())
+//	defer cancel()
+//	for msg := range fanin.FanIn(ctx, boring("Joe"), boring("Ann")) {
+//		fmt.Println(msg)
+//	}
+package fanin
+
+import (
+	"context"
+	"sync"
+)
+
+// FanIn returns a channel that receives every value sent on inputs, in
+// the order they arrive, like fanInSimple. It closes the channel once
+// every input is closed and drained, or once ctx is done, and then leaves
+// no goroutine behind. Values not yet received when ctx is done are lost.
+//
+// The caller must keep receiving until the channel is closed or cancel
+// ctx; a value already taken from an input waits to be delivered until
+// one of the two happens.
+func FanIn[T any](ctx context.Context, inputs ...<-chan T) <-chan T {
+	c := make(chan T)
+	var wg sync.WaitGroup
+	wg.Add(len(inputs))
+	for _, input := range inputs {
+		go func(input <-chan T) {
+			defer wg.Done()
+			forward(ctx, input, c)
+		}(input)
+	}
+	go func() {
+		wg.Wait()
+		close(c)
+	}()
+	return c
+}
+
+// forward sends the values of input to c until input is closed or ctx is done.
+func forward[T any](ctx context.Context, input <-chan T, c chan<- T) {
+	for {
+		select {
+		case v, ok := <-input:
+			if !ok {
+				return
+			}
+			select {
+			case c <- v:
+			case <-ctx.Done():
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
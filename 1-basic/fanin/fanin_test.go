@@ -0,0 +1,60 @@
+package fanin_test
+
+import (
+	"context"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"1-basic/fanin"
+)
+
+// waitGoroutines waits for the number of goroutines to fall back to want,
+// failing t if it does not within a second.
+func waitGoroutines(t *testing.T, want int) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > want; {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines left, want %d", runtime.NumGoroutine(), want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestFanInDrains(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	// Ann is closed from the start and dropped; Bob is closed once drained.
+	c := fanin.FanIn(context.Background(), filled("Joe", 3), filled("Ann", 0), filled("Bob", 2))
+	var got []string
+	for msg := range c { // ends once every input has drained
+		got = append(got, msg)
+	}
+	sort.Strings(got)
+	if want := "Bob Bob Joe Joe Joe"; strings.Join(got, " ") != want {
+		t.Errorf("got %v, want %s", got, want)
+	}
+	waitGoroutines(t, baseline)
+}
+
+func TestFanInNoInputs(t *testing.T) {
+	if _, ok := <-fanin.FanIn[int](context.Background()); ok {
+		t.Error("received from a fan-in of nothing")
+	}
+}
+
+func TestFanInCancel(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+	joe, ann := make(chan string), make(chan string) // never closed
+	c := fanin.FanIn(ctx, joe, ann)
+	go func() { joe <- "Joe 0" }()
+	if msg := <-c; msg != "Joe 0" {
+		t.Fatalf("got %q, want Joe 0", msg)
+	}
+	cancel()
+	for range c { // closes although its inputs are open
+	}
+	waitGoroutines(t, baseline)
+}
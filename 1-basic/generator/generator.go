@@ -0,0 +1,133 @@
+// Package generator makes generators, functions returning a channel backed
+// by a goroutine like boring in 3-generator, that can be stopped: every
+// generator takes a context, and its goroutine returns and closes the
+// channel once the context is done.
+//
+//	ctx, cancel := context.WithCancel(context.Background())
+//	defer cancel() // stops the generator
+//	joe := generator.Generate(ctx, func(yield func(string) bool) {
+//		for i := 0; yield(fmt.Sprintf("Joe %d", i)); i++ {
+//			time.Sleep(time.Duration(rand.Intn(1e3)) * time.Millisecond)
+//		}
+//	})
+//	for i := 0; i < 5; i++ {
+//		fmt.Println(<-joe)
+//	}
+//
+// Generators convert to and from the iterators of package iter.
+package generator
+
+import (
+	"context"
+	"iter"
+	"time"
+
+	"1-basic/clock"
+)
+
+// Generate returns a channel receiving the values f yields. f runs in its
+// own goroutine; yield blocks until its value is received, and returns
+// false once ctx is done, when f should return. The channel is closed when
+// f returns.
+//
+// The goroutine is gone once f returns, so f must not go on without
+// yielding after yield returned false. Every generator of this package
+// honors that.
+func Generate[T any](ctx context.Context, f func(yield func(T) bool)) <-chan T {
+	c := make(chan T)
+	go func() {
+		defer close(c)
+		stopped := false
+		f(func(v T) bool {
+			if stopped {
+				return false
+			}
+			select {
+			case c <- v:
+				return true
+			case <-ctx.Done():
+				stopped = true
+				return false
+			}
+		})
+	}()
+	return c
+}
+
+// Count returns a generator of the integers from start up.
+func Count(ctx context.Context, start int) <-chan int {
+	return Generate(ctx, func(yield func(int) bool) {
+		for i := start; yield(i); i++ {
+		}
+	})
+}
+
+// Repeat returns a generator of v, over and over.
+func Repeat[T any](ctx context.Context, v T) <-chan T {
+	return Generate(ctx, func(yield func(T) bool) {
+		for yield(v) {
+		}
+	})
+}
+
+// FromSlice returns a generator of the elements of s, in order.
+func FromSlice[T any](ctx context.Context, s []T) <-chan T {
+	return Generate(ctx, func(yield func(T) bool) {
+		for _, v := range s {
+			if !yield(v) {
+				return
+			}
+		}
+	})
+}
+
+// Ticker returns a generator of the times of ticks every d on clk, like
+// time.Ticker but stoppable with ctx. Like time.Ticker, it drops ticks
+// the receiver is too slow for. It panics if d <= 0.
+func Ticker(ctx context.Context, clk clock.Clock, d time.Duration) <-chan time.Time {
+	if d <= 0 {
+		panic("generator: non-positive interval for Ticker")
+	}
+	return Generate(ctx, func(yield func(time.Time) bool) {
+		next := clk.Now().Add(d)
+		for {
+			var tick time.Time
+			due, timer := clk.At(next)
+			select {
+			case tick = <-due:
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			}
+			if !yield(tick) {
+				return
+			}
+			for next = next.Add(d); !next.After(clk.Now()); next = next.Add(d) {
+				// dropped while the receiver was busy
+			}
+		}
+	})
+}
+
+// FromSeq returns a generator of the values of seq.
+func FromSeq[T any](ctx context.Context, seq iter.Seq[T]) <-chan T {
+	return Generate(ctx, seq)
+}
+
+// Seq returns an iterator over the values received from c, until c is
+// closed or ctx is done. Breaking out of the loop stops receiving but
+// leaves the generator behind c running; cancel its context to stop it.
+func Seq[T any](ctx context.Context, c <-chan T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			select {
+			case v, ok := <-c:
+				if !ok || !yield(v) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
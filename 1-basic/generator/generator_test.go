@@ -0,0 +1,135 @@
+package generator_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"1-basic/clock"
+	"1-basic/generator"
+)
+
+// closes cancels the generator behind c and fails t unless c then closes.
+// Values already on their way may still arrive before it does.
+func closes[T any](t *testing.T, cancel context.CancelFunc, c <-chan T) {
+	t.Helper()
+	cancel()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-c:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("generator still running after cancel")
+		}
+	}
+}
+
+func TestCount(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := generator.Count(ctx, 5)
+	for want := 5; want < 10; want++ {
+		if got := <-c; got != want {
+			t.Fatalf("got %d, want %d", got, want)
+		}
+	}
+	closes(t, cancel, c)
+}
+
+func TestRepeat(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := generator.Repeat(ctx, "Joe")
+	for i := 0; i < 3; i++ {
+		if got := <-c; got != "Joe" {
+			t.Fatalf("got %q, want Joe", got)
+		}
+	}
+	closes(t, cancel, c)
+}
+
+func TestFromSlice(t *testing.T) {
+	var got []int
+	for v := range generator.FromSlice(context.Background(), []int{1, 2, 3}) {
+		got = append(got, v)
+	}
+	if len(got) != 3 || got[0] != 1 || got[1] != 2 || got[2] != 3 {
+		t.Errorf("got %v, want [1 2 3]", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := generator.FromSlice(ctx, make([]int, 100))
+	<-c
+	closes(t, cancel, c)
+}
+
+func TestTicker(t *testing.T) {
+	start := time.Unix(0, 0)
+	fake := clock.NewFake(start)
+	ctx, cancel := context.WithCancel(context.Background())
+	c := generator.Ticker(ctx, fake, time.Second)
+	for i := 1; i <= 3; i++ {
+		tick, _ := clock.Recv(fake, c, time.Second)
+		if want := start.Add(time.Duration(i) * time.Second); !tick.Equal(want) {
+			t.Fatalf("tick %d at %v, want %v", i, tick, want)
+		}
+	}
+	<-fake.Blocked(1) // waiting for the next tick
+	closes(t, cancel, c)
+	if n := fake.Pending(); n != 0 {
+		t.Errorf("%d waits left on the clock after cancel", n)
+	}
+}
+
+func TestSeqBreak(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := generator.Count(ctx, 0)
+	var got []int
+	for v := range generator.Seq(ctx, c) {
+		got = append(got, v)
+		if v == 2 {
+			break
+		}
+	}
+	if len(got) != 3 {
+		t.Errorf("got %v, want [0 1 2]", got)
+	}
+	// The break left the rest of c alone.
+	if v := <-c; v != 3 {
+		t.Errorf("after break, c delivered %d, want 3", v)
+	}
+}
+
+func TestSeqContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for v := range generator.Seq(ctx, make(chan int)) { // never sends
+		t.Fatalf("got %d from a channel that never sends", v)
+	}
+}
+
+func TestFromSeqIgnoringFalse(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results := make(chan []bool, 1)
+	// A seq that keeps yielding after yield returned false.
+	c := generator.FromSeq(ctx, func(yield func(int) bool) {
+		var oks []bool
+		for i := 0; i < 5; i++ {
+			oks = append(oks, yield(i))
+		}
+		results <- oks
+	})
+	// Nothing receives from c until the seq is over, so every yield
+	// must have seen ctx done instead of sending.
+	for i, ok := range <-results {
+		if ok {
+			t.Errorf("yield %d = true after ctx was done", i)
+		}
+	}
+	if v, ok := <-c; ok {
+		t.Errorf("got %d, want c closed", v)
+	}
+}
@@ -1,3 +1,3 @@
 module 1-basic
 
-go 1.18
\ No newline at end of file
+go 1.23
\ No newline at end of file
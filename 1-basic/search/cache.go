@@ -0,0 +1,177 @@
+package search
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"1-basic/clock"
+)
+
+// Cache remembers the results of a backend, so that a repeated query does
+// not hit it again. It holds at most size results (0 means no bound),
+// forgetting the least recently used first, each for at most ttl (0 means
+// results never expire). Errors are not cached.
+//
+// Concurrent identical queries share one call to the backend. That call
+// is cancelled only once every query waiting for it has given up.
+//
+// Its Search method is a Search, so it composes like any backend:
+//
+//	web := search.NewCache(clock.Real, Web, 1000, time.Minute)
+//	google := search.Timed[string, Result](clock.Real, 80*time.Millisecond)(web.Search, Image, Video)
+type Cache[Q comparable, R any] struct {
+	backend Search[Q, R]
+	clk     clock.Clock
+	size    int
+	ttl     time.Duration
+
+	mu       sync.Mutex
+	order    *list.List          // of *cacheEntry[Q, R], most recently used first
+	entries  map[Q]*list.Element // index into order
+	inflight map[Q]*call[R]      // backend calls in progress
+	stats    CacheStats
+}
+
+// CacheStats counts how a Cache answered queries.
+type CacheStats struct {
+	Hits   int // answered from the cache
+	Misses int // answered by calling the backend
+	Shared int // answered by joining a call another query made
+}
+
+type cacheEntry[Q comparable, R any] struct {
+	query  Q
+	result R
+	at     time.Time // when result was cached
+}
+
+// call is a backend call shared by the queries waiting for it.
+type call[R any] struct {
+	done    chan struct{} // closed when result and err are set
+	result  R
+	err     error
+	cancel  context.CancelFunc
+	waiters int // guarded by the Cache's mu
+}
+
+// NewCache returns a Cache of backend's results, measuring ttl on clk.
+func NewCache[Q comparable, R any](clk clock.Clock, backend Search[Q, R], size int, ttl time.Duration) *Cache[Q, R] {
+	return &Cache[Q, R]{
+		backend:  backend,
+		clk:      clk,
+		size:     size,
+		ttl:      ttl,
+		order:    list.New(),
+		entries:  make(map[Q]*list.Element),
+		inflight: make(map[Q]*call[R]),
+	}
+}
+
+// Search answers query from the cache, or from the backend on a miss.
+func (c *Cache[Q, R]) Search(ctx context.Context, query Q) (R, error) {
+	c.mu.Lock()
+	if result, ok := c.lookup(query); ok {
+		c.stats.Hits++
+		c.mu.Unlock()
+		return result, nil
+	}
+	cl, ok := c.inflight[query]
+	if ok {
+		c.stats.Shared++
+	} else {
+		c.stats.Misses++
+		cl = c.start(query)
+	}
+	cl.waiters++
+	c.mu.Unlock()
+
+	select {
+	case <-cl.done:
+		return cl.result, cl.err
+	case <-ctx.Done():
+		c.mu.Lock()
+		cl.waiters--
+		if cl.waiters == 0 {
+			cl.cancel()                  // nobody wants the answer any more
+			if c.inflight[query] == cl { // unless it just ended, and maybe started afresh
+				delete(c.inflight, query)
+			}
+		}
+		c.mu.Unlock()
+		var zero R
+		return zero, ctx.Err()
+	}
+}
+
+// lookup returns the cached result of query, if it has not expired.
+// c.mu must be held.
+func (c *Cache[Q, R]) lookup(query Q) (R, bool) {
+	var zero R
+	e, ok := c.entries[query]
+	if !ok {
+		return zero, false
+	}
+	entry := e.Value.(*cacheEntry[Q, R])
+	if c.ttl > 0 && c.clk.Since(entry.at) >= c.ttl {
+		c.order.Remove(e)
+		delete(c.entries, query)
+		return zero, false
+	}
+	c.order.MoveToFront(e)
+	return entry.result, true
+}
+
+// start calls the backend for query in its own goroutine.
+// The call does not run in the context of the query that started it,
+// because other queries may join it. c.mu must be held.
+func (c *Cache[Q, R]) start(query Q) *call[R] {
+	ctx, cancel := context.WithCancel(context.Background())
+	cl := &call[R]{done: make(chan struct{}), cancel: cancel}
+	c.inflight[query] = cl
+	go func() {
+		defer cancel()
+		result, err := c.backend(ctx, query)
+		c.mu.Lock()
+		if c.inflight[query] == cl { // unless abandoned, and maybe started afresh
+			delete(c.inflight, query)
+		}
+		if err == nil {
+			c.store(query, result)
+		}
+		cl.result, cl.err = result, err
+		c.mu.Unlock()
+		close(cl.done)
+	}()
+	return cl
+}
+
+// store caches result, evicting the least recently used result if the
+// cache is full. c.mu must be held.
+func (c *Cache[Q, R]) store(query Q, result R) {
+	if e, ok := c.entries[query]; ok {
+		c.order.Remove(e)
+	}
+	c.entries[query] = c.order.PushFront(&cacheEntry[Q, R]{query, result, c.clk.Now()})
+	if c.size > 0 && c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry[Q, R]).query)
+	}
+}
+
+// Len returns the number of results c holds, including expired ones
+// that have not been looked up since.
+func (c *Cache[Q, R]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Stats returns how c has answered queries so far.
+func (c *Cache[Q, R]) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
@@ -0,0 +1,117 @@
+package search_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"1-basic/clock"
+	"1-basic/search"
+)
+
+// gated is a backend that answers once release is closed, or fails
+// when its context is done. It counts its calls.
+type gated struct {
+	release   chan struct{}
+	cancelled chan struct{} // closed by the first call that sees ctx.Done
+
+	mu    sync.Mutex
+	calls int
+	once  sync.Once
+}
+
+func newGated() *gated {
+	return &gated{release: make(chan struct{}), cancelled: make(chan struct{})}
+}
+
+func (g *gated) search(ctx context.Context, query string) (string, error) {
+	g.mu.Lock()
+	g.calls++
+	g.mu.Unlock()
+	select {
+	case <-g.release:
+		return query + " result", nil
+	case <-ctx.Done():
+		g.once.Do(func() { close(g.cancelled) })
+		return "", ctx.Err()
+	}
+}
+
+func (g *gated) Calls() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.calls
+}
+
+func TestCacheSharesCalls(t *testing.T) {
+	g := newGated()
+	cache := search.NewCache(clock.Real, g.search, 0, 0)
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if result, err := cache.Search(context.Background(), "golang"); err != nil || result != "golang result" {
+				t.Errorf("Search = %q, %v, want golang result", result, err)
+			}
+		}()
+	}
+	for cache.Stats().Misses+cache.Stats().Shared < 3 {
+		time.Sleep(time.Millisecond) // until all three wait
+	}
+	close(g.release)
+	wg.Wait()
+	if _, err := cache.Search(context.Background(), "golang"); err != nil {
+		t.Fatal(err)
+	}
+	want := search.CacheStats{Hits: 1, Misses: 1, Shared: 2}
+	if got := cache.Stats(); got != want || g.Calls() != 1 {
+		t.Errorf("stats %+v after %d calls, want %+v after 1", got, g.Calls(), want)
+	}
+}
+
+func TestCacheAbandon(t *testing.T) {
+	g := newGated()
+	cache := search.NewCache(clock.Real, g.search, 0, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error)
+	go func() {
+		_, err := cache.Search(ctx, "golang")
+		errc <- err
+	}()
+	for g.Calls() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-errc; err != context.Canceled {
+		t.Errorf("Search = %v, want context.Canceled", err)
+	}
+	<-g.cancelled // the only waiter gave up, so the call is cancelled
+
+	// The abandoned call is forgotten; the next query calls afresh.
+	close(g.release)
+	if result, err := cache.Search(context.Background(), "golang"); err != nil || result != "golang result" {
+		t.Errorf("Search = %q, %v, want golang result", result, err)
+	}
+	if calls := g.Calls(); calls != 2 {
+		t.Errorf("backend called %d times, want 2", calls)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	g := newGated()
+	close(g.release)
+	cache := search.NewCache(clk, g.search, 0, time.Minute)
+	for _, advance := range []time.Duration{0, 59 * time.Second, time.Second} {
+		clk.Advance(advance)
+		if _, err := cache.Search(context.Background(), "golang"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := search.CacheStats{Hits: 1, Misses: 2}
+	if got := cache.Stats(); got != want {
+		t.Errorf("stats %+v, want %+v: the result expires after a minute", got, want)
+	}
+}
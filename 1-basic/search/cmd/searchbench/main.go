@@ -0,0 +1,216 @@
+// Command searchbench measures the Google Search examples 1.0 to 3.0 by
+// running each of them many times against the same seeded fake backends,
+// and reports the latency percentiles of a query, how often it timed out
+// or failed, and how many results it returned.
+//
+//	go run ./search/cmd/searchbench -n 2000 -latency bimodal -csv bench.csv
+//
+// Every version gets fresh backends drawing from the same seeds, so they
+// see the same latencies; only the interleaving of concurrent queries,
+// which -parallel controls, differs from run to run.
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"1-basic/clock"
+	"1-basic/search"
+	"1-basic/search/searchtest"
+)
+
+var (
+	n         = flag.Int("n", 1000, "queries per version")
+	parallel  = flag.Int("parallel", 50, "queries in flight at once")
+	seed      = flag.Int64("seed", 1, "seed of the fake backends")
+	model     = flag.String("latency", "uniform", "latency of the backends: fixed, uniform, normal, lognormal or bimodal")
+	errorRate = flag.Float64("errors", 0, "probability that a backend fails a query")
+	timeout   = flag.Duration("timeout", 80*time.Millisecond, "timeout of versions 2.1 and 3.0")
+	csvFile   = flag.String("csv", "", "also write the report as CSV to this file")
+)
+
+// latencies are the models -latency chooses from.
+// uniform is the rand.Intn(100) milliseconds of fakeSearch.
+var latencies = map[string]searchtest.Latency{
+	"fixed":     searchtest.Fixed(50 * time.Millisecond),
+	"uniform":   searchtest.Uniform(0, 100*time.Millisecond),
+	"normal":    searchtest.Normal(50*time.Millisecond, 15*time.Millisecond),
+	"lognormal": searchtest.LogNormal(30*time.Millisecond, 0.6),
+	"bimodal": searchtest.Bimodal(
+		searchtest.Uniform(10*time.Millisecond, 40*time.Millisecond),
+		searchtest.Uniform(100*time.Millisecond, 300*time.Millisecond),
+		0.1,
+	),
+}
+
+type google = search.Search[string, *search.Response[string]]
+
+// version builds one of the examples out of the replicas of each kind of backend.
+type version struct {
+	name  string
+	build func(web, image, video []search.Search[string, string]) google
+}
+
+var versions = []version{
+	{"1.0", func(web, image, video []search.Search[string, string]) google {
+		return search.Sequential[string, string](clock.Real)(web[0], image[0], video[0])
+	}},
+	{"2.0", func(web, image, video []search.Search[string, string]) google {
+		return search.Concurrent[string, string](clock.Real)(web[0], image[0], video[0])
+	}},
+	{"2.1", func(web, image, video []search.Search[string, string]) google {
+		return search.Timed[string, string](clock.Real, *timeout)(web[0], image[0], video[0])
+	}},
+	{"3.0", func(web, image, video []search.Search[string, string]) google {
+		return search.Timed[string, string](clock.Real, *timeout)(
+			search.First(web...), search.First(image...), search.First(video...))
+	}},
+}
+
+// replicas is the number of replicas of each kind, as in Google Search 3.0.
+const replicas = 3
+
+// report summarizes the queries of one version.
+type report struct {
+	version       string
+	p50, p90, p99 time.Duration
+	timeouts      float64 // share of queries with a backend that timed out
+	failures      float64 // share of queries with a backend that failed
+	results       float64 // mean number of results per query
+}
+
+func main() {
+	flag.Parse()
+	if *n < 1 {
+		log.Fatalf("-n must be at least 1, not %d", *n)
+	}
+	if *parallel < 1 {
+		log.Fatalf("-parallel must be at least 1, not %d", *parallel)
+	}
+	latency, ok := latencies[*model]
+	if !ok {
+		log.Fatalf("unknown latency model %q", *model)
+	}
+	var reports []report
+	for _, v := range versions {
+		web := backends("web", 0, latency)
+		image := backends("image", replicas, latency)
+		video := backends("video", 2*replicas, latency)
+		reports = append(reports, run(v.name, v.build(web, image, video)))
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "version\tp50\tp90\tp99\ttimeouts\tfailures\tresults\t")
+	for _, r := range reports {
+		fmt.Fprintf(w, "%s\t%v\t%v\t%v\t%.1f%%\t%.1f%%\t%.2f\t\n", r.version,
+			r.p50.Round(time.Millisecond/10), r.p90.Round(time.Millisecond/10), r.p99.Round(time.Millisecond/10),
+			100*r.timeouts, 100*r.failures, r.results)
+	}
+	w.Flush()
+
+	if *csvFile != "" {
+		if err := writeCSV(*csvFile, reports); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// backends returns the replicas of one kind of backend, seeded from offset.
+func backends(kind string, offset int, latency searchtest.Latency) []search.Search[string, string] {
+	s := make([]search.Search[string, string], replicas)
+	for i := range s {
+		s[i] = searchtest.Backend{
+			Kind:      fmt.Sprintf("%s %d", kind, i+1),
+			Latency:   latency,
+			ErrorRate: *errorRate,
+			Seed:      *seed + int64(offset+i),
+		}.Search()
+	}
+	return s
+}
+
+// run sends google -n queries, -parallel at a time, and summarizes them.
+func run(name string, google google) report {
+	elapsed := make([]time.Duration, *n)
+	responses := make([]*search.Response[string], *n)
+	queries := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < *parallel; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queries {
+				start := time.Now()
+				responses[i], _ = google(context.Background(), "golang")
+				elapsed[i] = time.Since(start)
+			}
+		}()
+	}
+	for i := 0; i < *n; i++ {
+		queries <- i
+	}
+	close(queries)
+	wg.Wait()
+
+	r := report{version: name}
+	for _, resp := range responses {
+		if resp.Count(search.TimedOut) > 0 {
+			r.timeouts++
+		}
+		if resp.Count(search.Failed) > 0 {
+			r.failures++
+		}
+		r.results += float64(len(resp.Results()))
+	}
+	r.timeouts /= float64(*n)
+	r.failures /= float64(*n)
+	r.results /= float64(*n)
+	sort.Slice(elapsed, func(i, j int) bool { return elapsed[i] < elapsed[j] })
+	r.p50 = percentile(elapsed, 50)
+	r.p90 = percentile(elapsed, 90)
+	r.p99 = percentile(elapsed, 99)
+	return r
+}
+
+// percentile returns the p-th percentile of sorted by nearest rank.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(p/100*float64(len(sorted))+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+func writeCSV(name string, reports []report) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	w.Write([]string{"version", "p50_ms", "p90_ms", "p99_ms", "timeout_rate", "failure_rate", "results"})
+	ms := func(d time.Duration) string {
+		return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64)
+	}
+	num := func(f float64) string { return strconv.FormatFloat(f, 'f', 4, 64) }
+	for _, r := range reports {
+		w.Write([]string{r.version, ms(r.p50), ms(r.p90), ms(r.p99), num(r.timeouts), num(r.failures), num(r.results)})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,42 @@
+// Command searchd serves the Google Search 3.0 of the examples over HTTP:
+// replicated fake Web, Image and Video backends with an 80ms timeout,
+// whose pages are merged into one ranked result page.
+//
+//	go run ./search/cmd/searchd &
+//	curl 'localhost:8080/search?q=golang'
+package main
+
+import (
+	"flag"
+	"log"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"1-basic/clock"
+	"1-basic/search"
+	"1-basic/search/searchhttp"
+	"1-basic/search/searchtest"
+)
+
+var (
+	addr    = flag.String("addr", "localhost:8080", "address to listen on")
+	timeout = flag.Duration("timeout", 80*time.Millisecond, "how long to wait for the backends")
+)
+
+func main() {
+	flag.Parse()
+	fake := func(kind string) search.Search[string, []search.Result] {
+		return searchtest.Backend{Kind: kind, Seed: rand.Int63()}.Results()
+	}
+	google := search.Timed[string, []search.Result](clock.Real, *timeout)(
+		search.First(fake("web 1"), fake("web 2"), fake("web 3")),
+		search.First(fake("image 1"), fake("image 2"), fake("image 3")),
+		search.First(fake("video 1"), fake("video 2"), fake("video 3")),
+	)
+	handler := searchhttp.NewHandler(google, "web", "image", "video")
+	handler.Merge = search.Merge
+	http.Handle("/search", handler)
+	log.Printf("listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
@@ -0,0 +1,113 @@
+package search
+
+import (
+	"context"
+	"time"
+
+	"1-basic/clock"
+)
+
+// Backend is a Search that Deadline either waits for or gives up on.
+type Backend[Q, R any] struct {
+	Search   Search[Q, R]
+	Required bool // wait for it past the deadline
+}
+
+// Required returns a Backend that Deadline waits for.
+func Required[Q, R any](s Search[Q, R]) Backend[Q, R] {
+	return Backend[Q, R]{Search: s, Required: true}
+}
+
+// Optional returns a Backend that Deadline drops when time is up.
+func Optional[Q, R any](s Search[Q, R]) Backend[Q, R] {
+	return Backend[Q, R]{Search: s}
+}
+
+// Deadline returns a Search that queries backends concurrently and, once
+// timeout has passed, stops waiting for the optional ones: they are
+// reported as TimedOut and cancelled. It keeps waiting for required
+// backends, so bound them with Timeout. A timeout of 0 means no global
+// timeout. Nothing outlives the context: when it is done, every backend
+// still running is cancelled and reported with the context's error,
+// which for a deadline means TimedOut.
+func Deadline[Q, R any](clk clock.Clock, timeout time.Duration, backends ...Backend[Q, R]) Search[Q, *Response[R]] {
+	searches := make([]Search[Q, R], len(backends))
+	required := 0
+	for i, b := range backends {
+		searches[i] = b.Search
+		if b.Required {
+			required++
+		}
+	}
+	return func(ctx context.Context, query Q) (*Response[R], error) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel() // stops the backends we gave up on
+		resp := &Response[R]{Outcomes: make([]Outcome[R], len(backends))}
+		for i := range resp.Outcomes {
+			resp.Outcomes[i].Err = ErrTimedOut // until it answers
+		}
+		start := clk.Now()
+		c := fanOut(ctx, clk, query, searches)
+		var deadline <-chan time.Time
+		if timeout > 0 {
+			var timer clock.Timer
+			deadline, timer = clk.At(start.Add(timeout))
+			defer timer.Stop()
+		}
+		answered := make([]bool, len(backends))
+		waiting, waitingRequired := len(backends), required
+	wait:
+		for pastDeadline := false; waiting > 0 && !(pastDeadline && waitingRequired == 0); {
+			select {
+			case a := <-c:
+				resp.Outcomes[a.i] = Outcome[R]{a.result, a.err, a.latency}
+				answered[a.i] = true
+				waiting--
+				if backends[a.i].Required {
+					waitingRequired--
+				}
+			case <-deadline:
+				pastDeadline, deadline = true, nil
+			case <-ctx.Done():
+				break wait
+			}
+		}
+		waited := clk.Since(start)
+		for i := range resp.Outcomes {
+			if !answered[i] {
+				resp.Outcomes[i].Latency = waited
+				if err := ctx.Err(); err != nil {
+					resp.Outcomes[i].Err = err
+				}
+			}
+		}
+		return resp, nil
+	}
+}
+
+// Timeout returns a Search that gives backend timeout to answer.
+// After that, it cancels backend and fails with ErrTimedOut.
+// As for Deadline, a timeout of 0 means no timeout.
+func Timeout[Q, R any](clk clock.Clock, timeout time.Duration, backend Search[Q, R]) Search[Q, R] {
+	return func(ctx context.Context, query Q) (R, error) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		c := fanOut(ctx, clk, query, []Search[Q, R]{backend})
+		var deadline <-chan time.Time
+		if timeout > 0 {
+			var timer clock.Timer
+			deadline, timer = clk.At(clk.Now().Add(timeout))
+			defer timer.Stop()
+		}
+		select {
+		case a := <-c:
+			return a.result, a.err
+		case <-deadline:
+			var zero R
+			return zero, ErrTimedOut
+		case <-ctx.Done():
+			var zero R
+			return zero, ctx.Err()
+		}
+	}
+}
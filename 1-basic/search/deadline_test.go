@@ -0,0 +1,136 @@
+package search_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"1-basic/clock"
+	"1-basic/search"
+)
+
+// run runs s on "golang" in a goroutine and returns where its answer arrives.
+func run[R any](s search.Search[string, R]) <-chan R {
+	done := make(chan R, 1)
+	go func() {
+		resp, _ := s(context.Background(), "golang")
+		done <- resp
+	}()
+	return done
+}
+
+func TestDeadlineWaitsForRequired(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	cancelled := make(chan int, 1)
+	google := search.Deadline(clk, 10*time.Millisecond,
+		search.Required(sleeper(clk, "web", 20*time.Millisecond)),
+		search.Optional(blocker(cancelled, 1)),
+	)
+	done := run(google)
+	clk.BlockUntil(2) // the deadline and web
+	clk.Advance(10 * time.Millisecond)
+	clk.Advance(10 * time.Millisecond)
+	resp := <-done
+
+	web, video := resp.Outcomes[0], resp.Outcomes[1]
+	if web.Status() != search.Succeeded || web.Result != "web" || web.Latency != 20*time.Millisecond {
+		t.Errorf("required web = %+v, want web after 20ms", web)
+	}
+	if !errors.Is(video.Err, search.ErrTimedOut) || video.Latency != 20*time.Millisecond {
+		t.Errorf("optional video = %+v, want ErrTimedOut after 20ms", video)
+	}
+	if <-cancelled != 1 {
+		t.Error("video was not cancelled")
+	}
+}
+
+func TestDeadlineAllAnswered(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	google := search.Deadline(clk, 10*time.Millisecond,
+		search.Required(sleeper(clk, "web", 5*time.Millisecond)),
+		search.Optional(sleeper(clk, "image", 5*time.Millisecond)),
+	)
+	done := run(google)
+	clk.BlockUntil(3) // the deadline, web and image
+	clk.Advance(5 * time.Millisecond)
+	resp := <-done // before the deadline
+	if got := resp.Results(); len(got) != 2 || got[0] != "web" || got[1] != "image" {
+		t.Errorf("Results() = %q, want [web image]", got)
+	}
+}
+
+func TestDeadlineZeroMeansNone(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	google := search.Deadline(clk, 0, search.Optional(sleeper(clk, "image", time.Hour)))
+	done := run(google)
+	clk.BlockUntil(1) // only image
+	clk.Advance(time.Hour)
+	if resp := <-done; !resp.Complete() {
+		t.Errorf("Outcomes = %+v, want image answered", resp.Outcomes)
+	}
+}
+
+func TestDeadlineContext(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	cancelled := make(chan int, 1)
+	google := search.Deadline(clk, time.Hour, search.Required(blocker(cancelled, 0)))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	resp, err := google(ctx, "golang")
+	if err != nil {
+		t.Fatalf("Deadline failed: %v", err)
+	}
+	// Even a required backend is given up on once ctx is done.
+	if o := resp.Outcomes[0]; o.Status() != search.TimedOut || !errors.Is(o.Err, context.DeadlineExceeded) {
+		t.Errorf("required backend = %+v, want timed out by ctx", o)
+	}
+	<-cancelled
+}
+
+func TestTimeout(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	done := run(search.Timeout(clk, 10*time.Millisecond, sleeper(clk, "web", 5*time.Millisecond)))
+	clk.BlockUntil(2) // the timeout and web
+	clk.Advance(5 * time.Millisecond)
+	if result := <-done; result != "web" {
+		t.Errorf("Timeout = %q, want web", result)
+	}
+}
+
+func TestTimeoutExpires(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	cancelled := make(chan int, 1)
+	errc := make(chan error, 1)
+	go func() {
+		_, err := search.Timeout(clk, 10*time.Millisecond, blocker(cancelled, 0))(context.Background(), "golang")
+		errc <- err
+	}()
+	clk.BlockUntil(1) // the timeout
+	clk.Advance(10 * time.Millisecond)
+	if err := <-errc; err != search.ErrTimedOut {
+		t.Errorf("Timeout = %v, want ErrTimedOut", err)
+	}
+	<-cancelled
+}
+
+func TestTimeoutZeroMeansNone(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	done := run(search.Timeout(clk, 0, sleeper(clk, "web", time.Hour)))
+	clk.BlockUntil(1) // only web
+	clk.Advance(time.Hour)
+	if result := <-done; result != "web" {
+		t.Errorf("Timeout = %q, want web", result)
+	}
+}
+
+func TestTimeoutContext(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	cancelled := make(chan int, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := search.Timeout(clk, time.Hour, blocker(cancelled, 0))(ctx, "golang"); err != context.Canceled {
+		t.Errorf("Timeout = %v, want %v", err, context.Canceled)
+	}
+	<-cancelled
+}
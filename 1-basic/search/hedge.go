@@ -0,0 +1,157 @@
+package search
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"1-basic/clock"
+)
+
+// HedgePolicy decides how long Hedged waits for the replicas it queried
+// before it queries one more.
+type HedgePolicy interface {
+	// Delay returns how long to wait before hedging.
+	Delay() time.Duration
+	// Observe records how long a query took to answer, from the launch
+	// of its first replica, even if a hedge won.
+	Observe(latency time.Duration)
+}
+
+// FixedDelay is a HedgePolicy that always waits the same time.
+type FixedDelay time.Duration
+
+func (d FixedDelay) Delay() time.Duration { return time.Duration(d) }
+func (FixedDelay) Observe(time.Duration)  {}
+
+// PercentileDelay is a HedgePolicy that waits as long as the given
+// percentile of the latencies it observed recently, so that only the
+// slowest requests are hedged.
+type PercentileDelay struct {
+	percentile float64
+	initial    time.Duration
+
+	mu        sync.Mutex
+	latencies []time.Duration // ring buffer of recent latencies
+	next      int             // where the next latency goes
+}
+
+// NewPercentileDelay returns a PercentileDelay that waits the p-th
+// percentile (0 < p <= 100) of the last window latencies, or initial
+// until it has observed any. p above 100 counts as 100, and a window
+// below 0 as 0, which never learns.
+func NewPercentileDelay(p float64, window int, initial time.Duration) *PercentileDelay {
+	if p > 100 {
+		p = 100
+	}
+	if window < 0 {
+		window = 0
+	}
+	return &PercentileDelay{
+		percentile: p,
+		initial:    initial,
+		latencies:  make([]time.Duration, 0, window),
+	}
+}
+
+func (d *PercentileDelay) Delay() time.Duration {
+	d.mu.Lock()
+	sorted := append([]time.Duration(nil), d.latencies...)
+	d.mu.Unlock()
+	if len(sorted) == 0 {
+		return d.initial
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	i := int(math.Ceil(d.percentile/100*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+func (d *PercentileDelay) Observe(latency time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.latencies) < cap(d.latencies) {
+		d.latencies = append(d.latencies, latency)
+		return
+	}
+	if len(d.latencies) == 0 {
+		return // window of 0
+	}
+	d.latencies[d.next] = latency
+	d.next = (d.next + 1) % len(d.latencies)
+}
+
+// Hedged returns a Search like First that does not query every replica at
+// once. It queries the first replica, and each time policy.Delay passes
+// without an answer, or every replica queried so far has failed, it queries
+// the next one, up to max replicas (0 means all of them). The first
+// successful answer wins, and the others are cancelled.
+//
+// Hedging bounds tail latency at the cost of a few extra requests,
+// where First multiplies the load on the backends.
+func Hedged[Q, R any](clk clock.Clock, policy HedgePolicy, max int, replicas ...Search[Q, R]) Search[Q, R] {
+	n := len(replicas)
+	if max > 0 && max < n {
+		n = max
+	}
+	return func(ctx context.Context, query Q) (R, error) {
+		var zero R
+		if n == 0 {
+			return zero, errNoReplicas
+		}
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel() // stops the losers
+
+		c := make(chan answer[R], n) // buffered like fanOut
+		start := clk.Now()           // a winning hedge waited for the delay, too
+		launched := 0
+		var hedge <-chan time.Time // fires when it is time to query one more
+		var hedgeTimer clock.Timer // stops hedge once it is moot
+		defer func() {
+			if hedgeTimer != nil {
+				hedgeTimer.Stop()
+			}
+		}()
+		launch := func() {
+			i := launched
+			launched++
+			go func() {
+				result, err := replicas[i](ctx, query)
+				c <- answer[R]{i, result, err, clk.Since(start)}
+			}()
+			if hedgeTimer != nil {
+				hedgeTimer.Stop() // launching early, after failures
+			}
+			hedge, hedgeTimer = nil, nil
+			if launched < n {
+				hedge, hedgeTimer = clk.At(clk.Now().Add(policy.Delay()))
+			}
+		}
+
+		launch()
+		var err error
+		for answered := 0; answered < launched; {
+			select {
+			case a := <-c:
+				answered++
+				if a.err == nil {
+					policy.Observe(a.latency)
+					return a.result, nil
+				}
+				err = a.err
+				if answered == launched && launched < n {
+					launch() // everyone in flight failed; don't wait
+				}
+			case <-hedge:
+				launch()
+			case <-ctx.Done():
+				return zero, ctx.Err()
+			}
+		}
+		return zero, err
+	}
+}
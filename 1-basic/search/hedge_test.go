@@ -0,0 +1,82 @@
+package search_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"1-basic/clock"
+	"1-basic/search"
+)
+
+// recorder is a HedgePolicy with a fixed delay that records what it observes.
+type recorder struct {
+	delay time.Duration
+
+	mu       sync.Mutex
+	observed []time.Duration
+}
+
+func (r *recorder) Delay() time.Duration { return r.delay }
+
+func (r *recorder) Observe(latency time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.observed = append(r.observed, latency)
+}
+
+func TestHedgedObservesFromFirstLaunch(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	policy := &recorder{delay: 10 * time.Millisecond}
+	cancelled := make(chan int, 1)
+	hedged := search.Hedged(clk, policy, 0,
+		blocker(cancelled, 0),
+		sleeper(clk, "hedge", 5*time.Millisecond),
+	)
+	done := make(chan string)
+	go func() {
+		result, _ := hedged(context.Background(), "golang")
+		done <- result
+	}()
+	clk.BlockUntil(1) // waiting to hedge
+	clk.Advance(10 * time.Millisecond)
+	clk.BlockUntil(1) // the hedge sleeps
+	clk.Advance(5 * time.Millisecond)
+	if result := <-done; result != "hedge" {
+		t.Fatalf("Hedged = %q, want hedge", result)
+	}
+	<-cancelled
+	// The hedge took 5ms, but the query took 15ms; only the latter
+	// tells how long the primary is worth waiting for.
+	policy.mu.Lock()
+	defer policy.mu.Unlock()
+	if len(policy.observed) != 1 || policy.observed[0] != 15*time.Millisecond {
+		t.Errorf("observed %v, want [15ms]", policy.observed)
+	}
+}
+
+func TestPercentileDelay(t *testing.T) {
+	for _, tt := range []struct {
+		p      float64
+		window int
+		want   time.Duration
+	}{
+		{50, 10, 5 * time.Millisecond},
+		{90, 10, 9 * time.Millisecond},
+		{100, 10, 10 * time.Millisecond},
+		{150, 10, 10 * time.Millisecond}, // counts as 100
+		{0, 10, time.Millisecond},        // the fastest
+		{50, 4, 8 * time.Millisecond},    // of the last 4 only
+		{50, 0, time.Second},             // never learns
+		{50, -1, time.Second},            // counts as 0
+	} {
+		d := search.NewPercentileDelay(tt.p, tt.window, time.Second)
+		for ms := 1; ms <= 10; ms++ {
+			d.Observe(time.Duration(ms) * time.Millisecond)
+		}
+		if got := d.Delay(); got != tt.want {
+			t.Errorf("p%v of a window of %d: Delay = %v, want %v", tt.p, tt.window, got, tt.want)
+		}
+	}
+}
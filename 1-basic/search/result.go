@@ -0,0 +1,53 @@
+package search
+
+import (
+	"sort"
+	"time"
+)
+
+// Result is one hit on a result page.
+type Result struct {
+	Source  string        `json:"source"` // the backend that found it, such as "web"
+	Title   string        `json:"title"`  // what the page shows for it
+	URL     string        `json:"url"`    // identifies the result across backends
+	Score   float64       `json:"score"`  // relevance; higher ranks first
+	Latency time.Duration `json:"-"`      // how long the backend took to find it
+}
+
+// Merge makes one result page out of the pages of the backends that
+// succeeded, instead of putting them one after another in the order they
+// arrived. Results with the same URL are kept once, with the highest
+// score, and the page is ranked by score, then by latency.
+// Results without a URL cannot be told apart, so they are all kept.
+// Results without a latency get that of their backend.
+func Merge(resp *Response[[]Result]) []Result {
+	var page []Result
+	at := make(map[string]int) // index into page by URL
+	for _, o := range resp.Outcomes {
+		if o.Err != nil {
+			continue
+		}
+		for _, r := range o.Result {
+			if r.Latency == 0 {
+				r.Latency = o.Latency
+			}
+			i, ok := at[r.URL]
+			switch {
+			case r.URL == "":
+				page = append(page, r)
+			case !ok:
+				at[r.URL] = len(page)
+				page = append(page, r)
+			case r.Score > page[i].Score:
+				page[i] = r
+			}
+		}
+	}
+	sort.SliceStable(page, func(i, j int) bool {
+		if page[i].Score != page[j].Score {
+			return page[i].Score > page[j].Score
+		}
+		return page[i].Latency < page[j].Latency
+	})
+	return page
+}
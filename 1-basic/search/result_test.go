@@ -0,0 +1,45 @@
+package search_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"1-basic/search"
+)
+
+func TestMerge(t *testing.T) {
+	ms := time.Millisecond
+	resp := &search.Response[[]search.Result]{Outcomes: []search.Outcome[[]search.Result]{
+		{Result: []search.Result{
+			{Source: "web", URL: "golang.org", Score: 0.5},
+			{Source: "web", URL: "go.dev", Score: 0.9},
+			{Source: "web", Title: "no URL", Score: 0.1},
+		}, Latency: 30 * ms},
+		{Result: []search.Result{
+			{Source: "image", URL: "golang.org", Score: 0.7, Latency: 5 * ms}, // beats web's
+			{Source: "image", URL: "go.dev", Score: 0.2},                      // loses to web's
+			{Source: "image", URL: "gopher.png", Score: 0.7},                  // ties, but slower
+			{Source: "image", Title: "no URL either", Score: 0.1},
+		}, Latency: 10 * ms},
+		{Err: errors.New("video failed"), Result: []search.Result{
+			{Source: "video", URL: "golang.org", Score: 1},
+		}},
+	}}
+	want := []search.Result{
+		{Source: "web", URL: "go.dev", Score: 0.9, Latency: 30 * ms},
+		{Source: "image", URL: "golang.org", Score: 0.7, Latency: 5 * ms},
+		{Source: "image", URL: "gopher.png", Score: 0.7, Latency: 10 * ms},
+		{Source: "image", Title: "no URL either", Score: 0.1, Latency: 10 * ms},
+		{Source: "web", Title: "no URL", Score: 0.1, Latency: 30 * ms},
+	}
+	got := search.Merge(resp)
+	if len(got) != len(want) {
+		t.Fatalf("Merge = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("result %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,224 @@
+// Package search composes search backends the way the Google Search
+// examples build Google out of Web, Image and Video: one after another,
+// all at once, all at once with a timeout, and replicated.
+//
+// A Strategy combines backends into a Search whose result is a Response
+// recording how each backend did, so callers can decide whether a partial
+// page is good enough. First combines replicas into a single backend:
+//
+//	google := search.Timed[string, Result](clock.Real, 80*time.Millisecond)(
+//		search.First(Web1, Web2),
+//		search.First(Image1, Image2),
+//		search.First(Video1, Video2),
+//	)
+//	resp, _ := google(ctx, "golang")
+//	results := resp.Results()
+//
+// Backends that answer with pages of Results can have them merged into
+// one ranked page with Merge.
+//
+// Every Search takes a context.Context, and combinators cancel it for
+// backends whose answer is no longer wanted.
+package search
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"1-basic/clock"
+)
+
+// ErrTimedOut is the error of a backend that did not answer in time.
+var ErrTimedOut = errors.New("search: timed out")
+
+var errNoReplicas = errors.New("search: no replicas")
+
+// Search is a search backend: it answers a query with a result or an error.
+// It should give up when ctx is done.
+type Search[Q, R any] func(ctx context.Context, query Q) (R, error)
+
+// Strategy combines backends into one Search whose Response reports on
+// each of them. The combined Search never fails; look at the Response.
+// Sequential, Concurrent and Timed make Strategies.
+type Strategy[Q, R any] func(backends ...Search[Q, R]) Search[Q, *Response[R]]
+
+// Status is how a backend did on a query.
+type Status int
+
+const (
+	Succeeded Status = iota
+	Failed
+	TimedOut
+)
+
+func (s Status) String() string {
+	switch s {
+	case Succeeded:
+		return "succeeded"
+	case Failed:
+		return "failed"
+	case TimedOut:
+		return "timed out"
+	}
+	return "unknown"
+}
+
+// Outcome is the answer of one backend.
+type Outcome[R any] struct {
+	Result  R             // valid if Err is nil
+	Err     error         // why the backend failed, or ErrTimedOut
+	Latency time.Duration // how long the backend took, or was waited for if it timed out
+}
+
+// Status reports how the backend did.
+// A backend that ran into the deadline of its context timed out, too.
+func (o Outcome[R]) Status() Status {
+	switch {
+	case o.Err == nil:
+		return Succeeded
+	case errors.Is(o.Err, ErrTimedOut), errors.Is(o.Err, context.DeadlineExceeded):
+		return TimedOut
+	}
+	return Failed
+}
+
+// Response is the answer of several backends to one query.
+type Response[R any] struct {
+	Outcomes []Outcome[R] // one per backend, in the order of the backends
+}
+
+// Results returns the results of the backends that succeeded.
+func (r *Response[R]) Results() []R {
+	var results []R
+	for _, o := range r.Outcomes {
+		if o.Err == nil {
+			results = append(results, o.Result)
+		}
+	}
+	return results
+}
+
+// Complete reports whether every backend succeeded.
+func (r *Response[R]) Complete() bool {
+	return r.Count(Succeeded) == len(r.Outcomes)
+}
+
+// Count returns the number of backends that ended with status.
+func (r *Response[R]) Count(status Status) int {
+	n := 0
+	for _, o := range r.Outcomes {
+		if o.Status() == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Sequential returns a Strategy that queries backends one after another,
+// like Google 1.0. Latencies are measured on clk.
+func Sequential[Q, R any](clk clock.Clock) Strategy[Q, R] {
+	return func(backends ...Search[Q, R]) Search[Q, *Response[R]] {
+		return func(ctx context.Context, query Q) (*Response[R], error) {
+			resp := &Response[R]{Outcomes: make([]Outcome[R], len(backends))}
+			for i, backend := range backends {
+				start := clk.Now()
+				result, err := backend(ctx, query)
+				resp.Outcomes[i] = Outcome[R]{result, err, clk.Since(start)}
+			}
+			return resp, nil
+		}
+	}
+}
+
+// Concurrent returns a Strategy that queries backends concurrently and waits
+// for all of them, like Google 2.0. Latencies are measured on clk.
+func Concurrent[Q, R any](clk clock.Clock) Strategy[Q, R] {
+	return func(backends ...Search[Q, R]) Search[Q, *Response[R]] {
+		return func(ctx context.Context, query Q) (*Response[R], error) {
+			resp := &Response[R]{Outcomes: make([]Outcome[R], len(backends))}
+			c := fanOut(ctx, clk, query, backends)
+			for range backends {
+				a := <-c
+				resp.Outcomes[a.i] = Outcome[R]{a.result, a.err, a.latency}
+			}
+			return resp, nil
+		}
+	}
+}
+
+// Timed returns a Strategy that queries backends concurrently but stops
+// waiting after timeout, like Google 2.1, or when ctx is done. Late backends
+// are reported as TimedOut, and their context is cancelled.
+// Use Deadline to wait for some backends past the timeout.
+func Timed[Q, R any](clk clock.Clock, timeout time.Duration) Strategy[Q, R] {
+	return func(backends ...Search[Q, R]) Search[Q, *Response[R]] {
+		optional := make([]Backend[Q, R], len(backends))
+		for i, backend := range backends {
+			optional[i] = Optional(backend)
+		}
+		return Deadline(clk, timeout, optional...)
+	}
+}
+
+// First returns a Search that sends the query to every replica and uses
+// the first successful response, like Google 3.0 does to avoid slow servers.
+// The losing replicas are cancelled. If every replica fails, First returns
+// the error of the last one.
+func First[Q, R any](replicas ...Search[Q, R]) Search[Q, R] {
+	race := Race(clock.Real, replicas...)
+	return func(ctx context.Context, query Q) (R, error) {
+		w, err := race(ctx, query)
+		return w.Result, err
+	}
+}
+
+// Winner is the answer of the replica that won a race.
+type Winner[R any] struct {
+	Result  R
+	Replica int           // index of the winning replica
+	Latency time.Duration // how long the winner took, measured on the race's clock
+}
+
+// Race is First, reporting which replica won and how long it took.
+func Race[Q, R any](clk clock.Clock, replicas ...Search[Q, R]) Search[Q, Winner[R]] {
+	return func(ctx context.Context, query Q) (Winner[R], error) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel() // stops the losers
+		err := errNoReplicas
+		c := fanOut(ctx, clk, query, replicas)
+		for range replicas {
+			a := <-c
+			if a.err == nil {
+				return Winner[R]{Result: a.result, Replica: a.i, Latency: a.latency}, nil
+			}
+			err = a.err
+		}
+		return Winner[R]{}, err
+	}
+}
+
+// answer is the answer of the i-th backend.
+type answer[R any] struct {
+	i       int
+	result  R
+	err     error
+	latency time.Duration
+}
+
+// fanOut queries every backend in its own goroutine.
+// The channel is buffered so that answers nobody waits for do not
+// leave their goroutines blocked forever: a goroutine ends as soon as
+// its backend returns, which a cancelled ctx hurries along.
+// Latencies are measured on clk.
+func fanOut[Q, R any](ctx context.Context, clk clock.Clock, query Q, backends []Search[Q, R]) <-chan answer[R] {
+	c := make(chan answer[R], len(backends))
+	start := clk.Now()
+	for i, backend := range backends {
+		go func(i int, backend Search[Q, R]) {
+			result, err := backend(ctx, query)
+			c <- answer[R]{i, result, err, clk.Since(start)}
+		}(i, backend)
+	}
+	return c
+}
@@ -0,0 +1,148 @@
+package search_test
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"1-basic/clock"
+	"1-basic/search"
+)
+
+// sleeper returns a backend that answers result after sleeping d on clk.
+func sleeper(clk clock.Clock, result string, d time.Duration) search.Search[string, string] {
+	return func(ctx context.Context, query string) (string, error) {
+		clk.Sleep(d)
+		return result, nil
+	}
+}
+
+// latencies returns the latencies of resp's outcomes.
+func latencies[R any](resp *search.Response[R]) []time.Duration {
+	var ds []time.Duration
+	for _, o := range resp.Outcomes {
+		ds = append(ds, o.Latency)
+	}
+	return ds
+}
+
+func TestSequentialLatency(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	google := search.Sequential[string, string](clk)(
+		sleeper(clk, "web", 10*time.Millisecond),
+		sleeper(clk, "image", 20*time.Millisecond),
+	)
+	done := make(chan *search.Response[string])
+	go func() {
+		resp, _ := google(context.Background(), "golang")
+		done <- resp
+	}()
+	// The second backend starts only when the first has answered.
+	clk.BlockUntil(1)
+	clk.Advance(10 * time.Millisecond)
+	clk.BlockUntil(1)
+	clk.Advance(20 * time.Millisecond)
+	resp := <-done
+	if got := latencies(resp); len(got) != 2 || got[0] != 10*time.Millisecond || got[1] != 20*time.Millisecond {
+		t.Errorf("latencies = %v, want [10ms 20ms]", got)
+	}
+	if !resp.Complete() {
+		t.Errorf("Complete() = false, want true")
+	}
+}
+
+func TestConcurrentLatency(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	google := search.Concurrent[string, string](clk)(
+		sleeper(clk, "web", 20*time.Millisecond),
+		sleeper(clk, "image", 20*time.Millisecond),
+	)
+	done := make(chan *search.Response[string])
+	go func() {
+		resp, _ := google(context.Background(), "golang")
+		done <- resp
+	}()
+	// Both backends sleep at once, so 20ms answers them both.
+	clk.BlockUntil(2)
+	clk.Advance(20 * time.Millisecond)
+	resp := <-done
+	if got := latencies(resp); len(got) != 2 || got[0] != 20*time.Millisecond || got[1] != 20*time.Millisecond {
+		t.Errorf("latencies = %v, want [20ms 20ms]", got)
+	}
+	if got := resp.Results(); len(got) != 2 || got[0] != "web" || got[1] != "image" {
+		t.Errorf("Results() = %q, want [web image] in the order of the backends", got)
+	}
+}
+
+// blocker returns a replica that never answers: it waits for ctx to be
+// done, reports that on cancelled, and fails with ctx.Err().
+func blocker(cancelled chan<- int, i int) search.Search[string, string] {
+	return func(ctx context.Context, query string) (string, error) {
+		<-ctx.Done()
+		cancelled <- i
+		return "", ctx.Err()
+	}
+}
+
+func TestFirstCancelsLosers(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	baseline := runtime.NumGoroutine()
+
+	const losers = 4
+	cancelled := make(chan int, losers)
+	replicas := []search.Search[string, string]{sleeper(clk, "winner", 10*time.Millisecond)}
+	for i := 1; i <= losers; i++ {
+		replicas = append(replicas, blocker(cancelled, i))
+	}
+	done := make(chan string)
+	go func() {
+		result, err := search.First(replicas...)(context.Background(), "golang")
+		if err != nil {
+			t.Errorf("First: %v", err)
+		}
+		done <- result
+	}()
+	clk.BlockUntil(1) // the winner sleeps
+	clk.Advance(10 * time.Millisecond)
+	if result := <-done; result != "winner" {
+		t.Errorf("First = %q, want winner", result)
+	}
+
+	seen := make(map[int]bool)
+	for len(seen) < losers {
+		select {
+		case i := <-cancelled:
+			seen[i] = true
+		case <-time.After(time.Second):
+			t.Fatalf("losers %v saw ctx.Done, want all %d", seen, losers)
+		}
+	}
+	// The goroutines of the replicas end once their answers are buffered.
+	for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > baseline; {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines left, want the %d from before First", runtime.NumGoroutine(), baseline)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRaceLatency(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	race := search.Race(clk,
+		sleeper(clk, "slow", 30*time.Millisecond),
+		sleeper(clk, "fast", 10*time.Millisecond),
+	)
+	done := make(chan search.Winner[string])
+	go func() {
+		w, _ := race(context.Background(), "golang")
+		done <- w
+	}()
+	clk.BlockUntil(2)
+	clk.Advance(10 * time.Millisecond)
+	w := <-done
+	if w.Result != "fast" || w.Replica != 1 || w.Latency != 10*time.Millisecond {
+		t.Errorf("Race = %+v, want fast, replica 1, after 10ms", w)
+	}
+	clk.Advance(20 * time.Millisecond) // lets the slow replica finish
+}
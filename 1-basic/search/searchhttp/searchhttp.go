@@ -0,0 +1,137 @@
+// Package searchhttp serves a search over HTTP as JSON.
+package searchhttp
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"1-basic/search"
+)
+
+// Reply is the JSON body the Handler writes.
+type Reply[R any] struct {
+	Query    string            `json:"query"`
+	Complete bool              `json:"complete"` // whether every backend succeeded
+	Elapsed  Millis            `json:"elapsed_ms"`
+	Backends []BackendReply[R] `json:"backends"`
+	Results  []ResultReply     `json:"results,omitempty"` // the merged page, if the Handler merges
+}
+
+// BackendReply reports on one backend.
+type BackendReply[R any] struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Latency Millis `json:"latency_ms"`
+	Result  *R     `json:"result,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ResultReply is a result on the merged page.
+type ResultReply struct {
+	search.Result
+	Latency Millis `json:"latency_ms"`
+}
+
+// Millis is a duration written to JSON as fractional milliseconds.
+type Millis time.Duration
+
+func (m Millis) MarshalJSON() ([]byte, error) {
+	return json.Marshal(float64(m) / float64(time.Millisecond))
+}
+
+// Handler serves GET /search?q=query by running Search and replying
+// with the outcome of each backend. The query is cancelled when the
+// client goes away.
+type Handler[R any] struct {
+	Search search.Search[string, *search.Response[R]]
+	Names  []string // names of the backends, in the order of their outcomes
+
+	// Merge, if set, makes one result page out of the Response,
+	// such as search.Merge does for pages of search.Results.
+	Merge func(*search.Response[R]) []search.Result
+
+	// ErrorLog logs replies that could not be encoded or written.
+	// Nil means the log package's standard logger.
+	ErrorLog *log.Logger
+}
+
+// NewHandler returns a Handler for s, whose backends are called names.
+func NewHandler[R any](s search.Search[string, *search.Response[R]], names ...string) *Handler[R] {
+	return &Handler[R]{Search: s, Names: names}
+}
+
+func (h *Handler[R]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		http.Error(w, "missing query parameter q", http.StatusBadRequest)
+		return
+	}
+	start := time.Now()
+	resp, err := h.Search(r.Context(), query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if r.Context().Err() != nil {
+		return // nobody is listening
+	}
+	reply := Reply[R]{
+		Query:    query,
+		Complete: resp.Complete(),
+		Elapsed:  Millis(time.Since(start)),
+		Backends: make([]BackendReply[R], len(resp.Outcomes)),
+	}
+	for i, o := range resp.Outcomes {
+		b := BackendReply[R]{
+			Name:    h.name(i),
+			Status:  o.Status().String(),
+			Latency: Millis(o.Latency),
+		}
+		if o.Err != nil {
+			b.Error = o.Err.Error()
+		} else {
+			result := o.Result
+			b.Result = &result
+		}
+		reply.Backends[i] = b
+	}
+	if h.Merge != nil {
+		for _, result := range h.Merge(resp) {
+			reply.Results = append(reply.Results, ResultReply{result, Millis(result.Latency)})
+		}
+	}
+	body, err := json.Marshal(reply)
+	if err != nil {
+		h.logf("encode reply to %q: %v", query, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(append(body, '\n')); err != nil {
+		h.logf("write reply to %q: %v", query, err)
+	}
+}
+
+func (h *Handler[R]) logf(format string, args ...interface{}) {
+	if h.ErrorLog != nil {
+		h.ErrorLog.Printf("searchhttp: "+format, args...)
+		return
+	}
+	log.Printf("searchhttp: "+format, args...)
+}
+
+// name returns the name of the i-th backend, or its number if it has none.
+func (h *Handler[R]) name(i int) string {
+	if i < len(h.Names) {
+		return h.Names[i]
+	}
+	return strconv.Itoa(i)
+}
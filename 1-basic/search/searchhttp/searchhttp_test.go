@@ -0,0 +1,168 @@
+package searchhttp_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"1-basic/search"
+	"1-basic/search/searchhttp"
+)
+
+// fixed returns a Search that always answers resp.
+func fixed[R any](resp *search.Response[R]) search.Search[string, *search.Response[R]] {
+	return func(ctx context.Context, query string) (*search.Response[R], error) {
+		return resp, nil
+	}
+}
+
+func TestReplyShape(t *testing.T) {
+	h := searchhttp.NewHandler(fixed(&search.Response[string]{Outcomes: []search.Outcome[string]{
+		{Result: "web result", Latency: 10 * time.Millisecond},
+		{Err: errors.New("image is down"), Latency: 2500 * time.Microsecond},
+		{Err: search.ErrTimedOut, Latency: 80 * time.Millisecond},
+	}}), "web", "image")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search?q=golang", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type %q, want application/json", ct)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["query"] != "golang" || got["complete"] != false {
+		t.Errorf("query %v, complete %v, want golang and false", got["query"], got["complete"])
+	}
+	if _, ok := got["elapsed_ms"].(float64); !ok {
+		t.Errorf("elapsed_ms is %v, want a number", got["elapsed_ms"])
+	}
+	if _, ok := got["results"]; ok {
+		t.Errorf("results present without Merge")
+	}
+	backends, _ := got["backends"].([]interface{})
+	want := []map[string]interface{}{
+		{"name": "web", "status": "succeeded", "latency_ms": 10.0, "result": "web result"},
+		{"name": "image", "status": "failed", "latency_ms": 2.5, "error": "image is down"},
+		{"name": "2", "status": "timed out", "latency_ms": 80.0, "error": search.ErrTimedOut.Error()},
+	}
+	if len(backends) != len(want) {
+		t.Fatalf("%d backends, want %d", len(backends), len(want))
+	}
+	for i, b := range backends {
+		b := b.(map[string]interface{})
+		if len(b) != len(want[i]) {
+			t.Errorf("backend %d = %v, want %v", i, b, want[i])
+			continue
+		}
+		for k, v := range want[i] {
+			if b[k] != v {
+				t.Errorf("backend %d: %s = %v, want %v", i, k, b[k], v)
+			}
+		}
+	}
+}
+
+func TestMergedResults(t *testing.T) {
+	h := searchhttp.NewHandler(fixed(&search.Response[[]search.Result]{Outcomes: []search.Outcome[[]search.Result]{
+		{Result: []search.Result{{Title: "Go", URL: "https://go.dev", Score: 1}}, Latency: time.Millisecond},
+	}}), "web")
+	h.Merge = search.Merge
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search?q=golang", nil))
+	var got struct {
+		Results []map[string]interface{} `json:"results"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Results) != 1 || got.Results[0]["latency_ms"] != 1.0 {
+		t.Errorf("results = %v, want one, with latency_ms 1", got.Results)
+	}
+}
+
+func TestBadRequests(t *testing.T) {
+	h := searchhttp.NewHandler(fixed(&search.Response[string]{}))
+	for _, tt := range []struct {
+		method, target string
+		status         int
+	}{
+		{http.MethodGet, "/search", http.StatusBadRequest},
+		{http.MethodGet, "/search?q=", http.StatusBadRequest},
+		{http.MethodPost, "/search?q=golang", http.StatusMethodNotAllowed},
+		{http.MethodHead, "/search?q=golang", http.StatusOK},
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%s %s: status %d, want %d", tt.method, tt.target, rec.Code, tt.status)
+		}
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/search?q=golang", nil))
+	if allow := rec.Header().Get("Allow"); allow != "GET, HEAD" {
+		t.Errorf("Allow %q, want GET, HEAD", allow)
+	}
+}
+
+func TestEncodeError(t *testing.T) {
+	var logged bytes.Buffer
+	h := searchhttp.NewHandler(fixed(&search.Response[chan int]{Outcomes: []search.Outcome[chan int]{
+		{Result: make(chan int)}, // JSON has no channels
+	}}))
+	h.ErrorLog = log.New(&logged, "", 0)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search?q=golang", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status %d, want 500", rec.Code)
+	}
+	if !strings.Contains(logged.String(), "encode reply") {
+		t.Errorf("logged %q, want the encoding error", logged.String())
+	}
+}
+
+func TestCancelPropagates(t *testing.T) {
+	started, cancelled := make(chan struct{}), make(chan struct{})
+	h := searchhttp.NewHandler(func(ctx context.Context, query string) (*search.Response[string], error) {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+		return nil, ctx.Err()
+	})
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/search?q=golang", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errc := make(chan error, 1)
+	go func() {
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		errc <- err
+	}()
+	<-started
+	cancel() // the client goes away
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("backend did not see the request cancelled")
+	}
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("client got %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,77 @@
+package searchtest
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Latency is a distribution of backend latencies.
+type Latency interface {
+	// Sample draws a latency using r.
+	Sample(r *rand.Rand) time.Duration
+}
+
+// LatencyFunc is a Latency drawn by calling a function.
+type LatencyFunc func(r *rand.Rand) time.Duration
+
+func (f LatencyFunc) Sample(r *rand.Rand) time.Duration { return f(r) }
+
+// Fixed is a Latency that is always d.
+func Fixed(d time.Duration) Latency {
+	return LatencyFunc(func(*rand.Rand) time.Duration { return d })
+}
+
+// Uniform is a Latency spread evenly over [min, max), like fakeSearch's
+// rand.Intn(100) milliseconds.
+func Uniform(min, max time.Duration) Latency {
+	return LatencyFunc(func(r *rand.Rand) time.Duration {
+		if max <= min {
+			return min
+		}
+		return min + time.Duration(r.Int63n(int64(max-min)))
+	})
+}
+
+// Normal is a Latency normally distributed around mean.
+// Samples below zero are taken as zero, and samples beyond the longest
+// Duration as the longest.
+func Normal(mean, stddev time.Duration) Latency {
+	return LatencyFunc(func(r *rand.Rand) time.Duration {
+		return toDuration(float64(mean) + r.NormFloat64()*float64(stddev))
+	})
+}
+
+// LogNormal is a Latency whose logarithm is normally distributed, with
+// the given median and shape sigma. Most answers come close to the
+// median and a few take much longer, which is how real servers behave.
+// Samples beyond the longest Duration are taken as the longest.
+func LogNormal(median time.Duration, sigma float64) Latency {
+	return LatencyFunc(func(r *rand.Rand) time.Duration {
+		return toDuration(float64(median) * math.Exp(sigma*r.NormFloat64()))
+	})
+}
+
+// Bimodal is a Latency drawn from slow with probability p and from fast
+// otherwise: a fast path with a slow tail, such as cache misses or
+// garbage-collection pauses.
+func Bimodal(fast, slow Latency, p float64) Latency {
+	return LatencyFunc(func(r *rand.Rand) time.Duration {
+		if r.Float64() < p {
+			return slow.Sample(r)
+		}
+		return fast.Sample(r)
+	})
+}
+
+// toDuration converts f to a Duration, clamping it to [0, math.MaxInt64].
+// A NaN, such as from a zero median times +Inf, is taken as zero.
+func toDuration(f float64) time.Duration {
+	switch {
+	case f < 0 || math.IsNaN(f):
+		return 0
+	case f >= math.MaxInt64:
+		return math.MaxInt64
+	}
+	return time.Duration(f)
+}
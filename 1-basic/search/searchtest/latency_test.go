@@ -0,0 +1,124 @@
+package searchtest_test
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"1-basic/search/searchtest"
+)
+
+const samples = 10000
+
+// sample draws samples latencies from l with a source seeded by seed.
+func sample(l searchtest.Latency, seed int64) []time.Duration {
+	r := rand.New(rand.NewSource(seed))
+	ds := make([]time.Duration, samples)
+	for i := range ds {
+		ds[i] = l.Sample(r)
+	}
+	return ds
+}
+
+func mean(ds []time.Duration) time.Duration {
+	var sum float64
+	for _, d := range ds {
+		sum += float64(d)
+	}
+	return time.Duration(sum / float64(len(ds)))
+}
+
+func median(ds []time.Duration) time.Duration {
+	sorted := append([]time.Duration(nil), ds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[len(sorted)/2]
+}
+
+// near reports whether got is within 5% of want.
+func near(got, want time.Duration) bool {
+	return math.Abs(float64(got-want)) <= 0.05*float64(want)
+}
+
+func TestDistributions(t *testing.T) {
+	fast, slow := searchtest.Fixed(time.Millisecond), searchtest.Fixed(time.Second)
+	for _, tt := range []struct {
+		name     string
+		latency  searchtest.Latency
+		min, max time.Duration // every sample is in [min, max]
+		stat     func([]time.Duration) time.Duration
+		want     time.Duration
+	}{
+		{"Fixed", fast, time.Millisecond, time.Millisecond, mean, time.Millisecond},
+		{"Uniform", searchtest.Uniform(10*time.Millisecond, 30*time.Millisecond),
+			10 * time.Millisecond, 30*time.Millisecond - 1, mean, 20 * time.Millisecond},
+		{"Uniform empty", searchtest.Uniform(time.Second, time.Millisecond),
+			time.Second, time.Second, mean, time.Second},
+		{"Normal", searchtest.Normal(100*time.Millisecond, 10*time.Millisecond),
+			0, math.MaxInt64, mean, 100 * time.Millisecond},
+		{"LogNormal", searchtest.LogNormal(50*time.Millisecond, 1),
+			0, math.MaxInt64, median, 50 * time.Millisecond},
+		{"Bimodal", searchtest.Bimodal(fast, slow, 0.1),
+			time.Millisecond, time.Second, mean, 100*time.Millisecond + 900*time.Microsecond},
+	} {
+		ds := sample(tt.latency, 1)
+		for _, d := range ds {
+			if d < tt.min || d > tt.max {
+				t.Fatalf("%s: sample %v outside [%v, %v]", tt.name, d, tt.min, tt.max)
+			}
+		}
+		if got := tt.stat(ds); !near(got, tt.want) {
+			t.Errorf("%s: got %v, want about %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSamplesClamped(t *testing.T) {
+	huge := time.Duration(math.MaxInt64)
+	for _, tt := range []struct {
+		name    string
+		latency searchtest.Latency
+	}{
+		{"Normal below zero", searchtest.Normal(time.Millisecond, time.Second)},
+		{"Normal beyond MaxInt64", searchtest.Normal(huge, huge)},
+		{"LogNormal to +Inf", searchtest.LogNormal(time.Hour, 1000)},
+		{"LogNormal of zero", searchtest.LogNormal(0, 1000)},
+	} {
+		for _, d := range sample(tt.latency, 1) {
+			if d < 0 {
+				t.Fatalf("%s: sample %v < 0", tt.name, d)
+			}
+		}
+	}
+	// Half the samples overflow to +Inf, which is taken as the longest Duration.
+	ds := sample(searchtest.LogNormal(time.Hour, 1000), 1)
+	longest := time.Duration(0)
+	for _, d := range ds {
+		longest = max(longest, d)
+	}
+	if longest != huge {
+		t.Errorf("LogNormal with a huge sigma: longest sample %v, want %v", longest, huge)
+	}
+}
+
+func TestSameSeedSameLatencies(t *testing.T) {
+	for name, l := range map[string]searchtest.Latency{
+		"Uniform":   searchtest.Uniform(0, time.Second),
+		"Normal":    searchtest.Normal(time.Second, time.Second),
+		"LogNormal": searchtest.LogNormal(time.Second, 1),
+		"Bimodal":   searchtest.Bimodal(searchtest.Fixed(0), searchtest.Fixed(time.Second), 0.5),
+	} {
+		a, b, c := sample(l, 7), sample(l, 7), sample(l, 8)
+		same := true
+		for i := range a {
+			if a[i] != b[i] {
+				t.Fatalf("%s: sample %d is %v, then %v with the same seed", name, i, a[i], b[i])
+			}
+			same = same && a[i] == c[i]
+		}
+		if same {
+			t.Errorf("%s: seeds 7 and 8 gave the same latencies", name)
+		}
+	}
+}
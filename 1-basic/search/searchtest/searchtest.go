@@ -0,0 +1,132 @@
+// Package searchtest provides fake search backends, like the fakeSearch
+// of the Google Search examples, for tests, demos and experiments.
+//
+// A Backend answers after a latency drawn from a configurable
+// distribution, fails at a given rate and goes down for given windows of
+// time. Its random numbers come from a seeded source, so a run of
+// backends queried in the same order behaves the same every time:
+//
+//	web := searchtest.Backend{
+//		Kind:      "web",
+//		Latency:   searchtest.LogNormal(30*time.Millisecond, 0.5),
+//		ErrorRate: 0.01,
+//		Seed:      1,
+//	}.Search()
+package searchtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"sync"
+	"time"
+
+	"1-basic/clock"
+	"1-basic/search"
+)
+
+var (
+	// ErrInjected is the error of a backend failing at its ErrorRate.
+	ErrInjected = errors.New("searchtest: injected failure")
+	// ErrUnavailable is the error of a backend queried during an Outage.
+	ErrUnavailable = errors.New("searchtest: backend unavailable")
+)
+
+// Outage is a window of time during which a Backend is down.
+// It is measured from when the Backend's Search was made.
+type Outage struct {
+	Start    time.Duration
+	Duration time.Duration
+}
+
+// Backend configures a fake search backend.
+type Backend struct {
+	Kind      string      // what the results say they are, such as "web"
+	Latency   Latency     // how long answers take; nil means Uniform(0, 100ms)
+	ErrorRate float64     // probability that a query fails with ErrInjected
+	Outages   []Outage    // when queries fail right away with ErrUnavailable
+	Seed      int64       // seeds the random numbers
+	Clock     clock.Clock // what the backend waits on; nil means clock.Real
+}
+
+// Search returns the backend. It draws a latency, waits for it unless its
+// context is done first, and then answers the query or fails.
+func (b Backend) Search() search.Search[string, string] {
+	return fake(b, func(_ *rand.Rand, query string, _ time.Duration) string {
+		return fmt.Sprintf("%s result for %q", b.Kind, query)
+	})
+}
+
+// documents is how many documents the backends of every kind share for
+// a query, so that backends of different kinds find some of the same.
+const documents = 10
+
+// Results returns the backend answering with a page of one to three
+// search.Results, like Search. Their URLs name one of a few documents
+// per query, so pages from different backends overlap.
+func (b Backend) Results() search.Search[string, []search.Result] {
+	return fake(b, func(r *rand.Rand, query string, latency time.Duration) []search.Result {
+		docs := r.Perm(documents)[:1+r.Intn(3)]
+		page := make([]search.Result, len(docs))
+		for i, doc := range docs {
+			page[i] = search.Result{
+				Source:  b.Kind,
+				Title:   fmt.Sprintf("Result %d for %q", doc, query),
+				URL:     fmt.Sprintf("https://example.com/%s/%d", url.PathEscape(query), doc),
+				Score:   r.Float64(),
+				Latency: latency,
+			}
+		}
+		return page
+	})
+}
+
+// fake returns the backend b describes, answering with what answer draws.
+func fake[R any](b Backend, answer func(r *rand.Rand, query string, latency time.Duration) R) search.Search[string, R] {
+	latency := b.Latency
+	if latency == nil {
+		latency = Uniform(0, 100*time.Millisecond)
+	}
+	clk := b.Clock
+	if clk == nil {
+		clk = clock.Real
+	}
+	outages := append([]Outage(nil), b.Outages...)
+	start := clk.Now()
+	var mu sync.Mutex // guards r, which is not safe for concurrent use
+	r := rand.New(rand.NewSource(b.Seed))
+
+	return func(ctx context.Context, query string) (R, error) {
+		var zero R
+		since := clk.Since(start)
+		for _, o := range outages {
+			if since >= o.Start && since < o.Start+o.Duration {
+				return zero, fmt.Errorf("%s: %w", b.Kind, ErrUnavailable)
+			}
+		}
+		mu.Lock()
+		d := latency.Sample(r)
+		fail := r.Float64() < b.ErrorRate
+		result := answer(r, query, d)
+		mu.Unlock()
+		answered, timer := clk.At(clk.Now().Add(d))
+		select {
+		case <-answered:
+		case <-ctx.Done():
+			timer.Stop()
+			return zero, ctx.Err()
+		}
+		if fail {
+			return zero, fmt.Errorf("%s: %w", b.Kind, ErrInjected)
+		}
+		return result, nil
+	}
+}
+
+// Fake returns a backend of the given kind that takes up to 100ms to answer
+// on clk, like fakeSearch. It gives up early when its context is done.
+func Fake(clk clock.Clock, kind string) search.Search[string, string] {
+	return Backend{Kind: kind, Seed: rand.Int63(), Clock: clk}.Search()
+}
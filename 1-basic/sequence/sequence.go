@@ -0,0 +1,200 @@
+// Package sequence delivers the values of several producers in lockstep,
+// like 5-restore-sequence makes Joe and Ann wait their turn, without the
+// Message{str, wait} plumbing: the Sequencer hands out the acknowledgements
+// the producers wait on.
+//
+//	boring := func(msg string) sequence.Producer[string] {
+//		return func(send func(string) bool) {
+//			for i := 0; send(fmt.Sprintf("%s %d", msg, i)); i++ {
+//				time.Sleep(time.Duration(rand.Intn(1e3)) * time.Millisecond)
+//			}
+//		}
+//	}
+//
+//	seq := sequence.New(ctx, boring("Joe"), boring("Ann"))
+//	defer seq.Close()
+//	for i := 0; i < 5; i++ {
+//		round, err := seq.Next() // a message from Joe, then one from Ann
+//		if err != nil {
+//			break
+//		}
+//		fmt.Println(round)
+//	}
+package sequence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrDone is returned by Next once every producer has returned
+// at the end of a round.
+var ErrDone = errors.New("sequence: producers done")
+
+// ErrClosed is returned by Next after Close.
+var ErrClosed = errors.New("sequence: closed")
+
+// IncompleteRoundError is returned by Next when some producers returned
+// in the middle of a round that others had sent values for, instead of
+// waiting for them forever.
+type IncompleteRoundError struct {
+	Round   int   // counting from 0
+	Missing []int // the producers that returned, in order
+}
+
+func (e *IncompleteRoundError) Error() string {
+	return fmt.Sprintf("sequence: round %d incomplete: producers %v returned", e.Round, e.Missing)
+}
+
+// Producer produces values for a Sequencer. It calls send with each value,
+// which blocks until the consumer is done with the round the value is
+// part of: every producer has sent one value and the consumer has asked
+// for the next round. send returns false once the Sequencer has stopped
+// or its context is done, and the Producer should then return. Returning ends the producer.
+type Producer[T any] func(send func(T) bool)
+
+// Sequencer delivers the values of its producers in rounds, one value from
+// each producer per round, in the order of the producers.
+// Its methods must not be called concurrently.
+type Sequencer[T any] struct {
+	ctx     context.Context
+	c       chan message[T]
+	acks    []chan struct{} // one per producer
+	quit    chan struct{}   // closed by Close
+	wg      sync.WaitGroup  // for the producers
+	ended   []bool          // producers that returned
+	round   int             // the round Next collects next
+	pending bool            // whether the producers wait for an ack for the last round
+	err     error           // why Next stopped, once it has
+	stop    sync.Once
+}
+
+// message is a value sent by, or the end of, the i-th producer.
+type message[T any] struct {
+	i     int
+	v     T
+	ended bool
+}
+
+// New starts the producers and returns a Sequencer for them,
+// which stops when ctx is done.
+func New[T any](ctx context.Context, producers ...Producer[T]) *Sequencer[T] {
+	s := &Sequencer[T]{
+		ctx:   ctx,
+		c:     make(chan message[T]),
+		acks:  make([]chan struct{}, len(producers)),
+		quit:  make(chan struct{}),
+		ended: make([]bool, len(producers)),
+	}
+	s.wg.Add(len(producers))
+	for i, p := range producers {
+		s.acks[i] = make(chan struct{}, 1)
+		go s.run(i, p)
+	}
+	return s
+}
+
+// run runs the i-th producer.
+func (s *Sequencer[T]) run(i int, p Producer[T]) {
+	defer s.wg.Done()
+	p(func(v T) bool {
+		select {
+		case s.c <- message[T]{i: i, v: v}:
+		case <-s.ctx.Done():
+			return false
+		case <-s.quit:
+			return false
+		}
+		select {
+		case <-s.acks[i]: // the round is over
+			return true
+		case <-s.ctx.Done():
+			return false
+		case <-s.quit:
+			return false
+		}
+	})
+	select {
+	case s.c <- message[T]{i: i, ended: true}:
+	case <-s.ctx.Done():
+	case <-s.quit:
+	}
+}
+
+// Next lets the producers go on from the last round and returns the next
+// one, with the value of each producer at its index. It returns ErrDone
+// if every producer returned instead, an *IncompleteRoundError if only
+// some did, and the error of the context if it is done.
+// Once Next has returned an error, it returns the same error again.
+func (s *Sequencer[T]) Next() ([]T, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if s.pending {
+		for _, ack := range s.acks {
+			ack <- struct{}{} // buffered; an ended producer just never reads it
+		}
+		s.pending = false
+	}
+
+	round := make([]T, len(s.acks))
+	sent := make([]bool, len(s.acks))
+	nsent, nended := 0, 0
+	for i := range s.ended {
+		if s.ended[i] {
+			nended++
+		}
+	}
+	for nsent+nended < len(s.acks) {
+		select {
+		case m := <-s.c:
+			if m.ended {
+				s.ended[m.i] = true
+				nended++
+				continue
+			}
+			round[m.i], sent[m.i] = m.v, true
+			nsent++
+		case <-s.ctx.Done():
+			return nil, s.fail(s.ctx.Err())
+		case <-s.quit:
+			return nil, s.fail(ErrClosed)
+		}
+	}
+
+	switch {
+	case nsent == 0:
+		return nil, s.fail(ErrDone)
+	case nended == 0:
+		s.round++
+		s.pending = true
+		return round, nil
+	}
+	err := &IncompleteRoundError{Round: s.round}
+	for i, ended := range s.ended {
+		if ended {
+			err.Missing = append(err.Missing, i)
+		}
+	}
+	return nil, s.fail(err)
+}
+
+// fail stops the Sequencer because of err, which Next then keeps returning.
+// The producers waiting in send are released with false.
+func (s *Sequencer[T]) fail(err error) error {
+	s.err = err
+	s.stop.Do(func() { close(s.quit) })
+	return err
+}
+
+// Close stops the Sequencer and waits for the producers to return.
+// Close may be called more than once.
+func (s *Sequencer[T]) Close() {
+	if s.err == nil {
+		s.err = ErrClosed
+	}
+	s.stop.Do(func() { close(s.quit) })
+	s.wg.Wait()
+}
@@ -0,0 +1,95 @@
+package sequence_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"1-basic/sequence"
+)
+
+// counter returns a Producer sending msg 0 to msg n-1, which reports on
+// returned when it returns.
+func counter(msg string, n int, returned chan<- string) sequence.Producer[string] {
+	return func(send func(string) bool) {
+		defer func() { returned <- msg }()
+		for i := 0; i < n && send(fmt.Sprintf("%s %d", msg, i)); i++ {
+		}
+	}
+}
+
+func TestRounds(t *testing.T) {
+	returned := make(chan string, 2)
+	seq := sequence.New(context.Background(), counter("Joe", 2, returned), counter("Ann", 2, returned))
+	defer seq.Close()
+	for i := 0; i < 2; i++ {
+		round, err := seq.Next()
+		if err != nil {
+			t.Fatalf("round %d: %v", i, err)
+		}
+		if want := fmt.Sprintf("[Joe %d Ann %d]", i, i); fmt.Sprint(round) != want {
+			t.Errorf("round %d = %v, want %v", i, round, want)
+		}
+	}
+	if _, err := seq.Next(); err != sequence.ErrDone {
+		t.Errorf("Next after the last round = %v, want ErrDone", err)
+	}
+	if _, err := seq.Next(); err != sequence.ErrDone {
+		t.Errorf("Next again = %v, want ErrDone again", err)
+	}
+}
+
+func TestIncompleteRound(t *testing.T) {
+	returned := make(chan string, 2)
+	seq := sequence.New(context.Background(), counter("Joe", 2, returned), counter("Ann", 1, returned))
+	defer seq.Close()
+	if _, err := seq.Next(); err != nil {
+		t.Fatal(err)
+	}
+	_, err := seq.Next()
+	var incomplete *sequence.IncompleteRoundError
+	if !errors.As(err, &incomplete) || incomplete.Round != 1 || fmt.Sprint(incomplete.Missing) != "[1]" {
+		t.Errorf("Next = %v, want round 1 incomplete without producer 1", err)
+	}
+}
+
+func TestClose(t *testing.T) {
+	returned := make(chan string, 2)
+	seq := sequence.New(context.Background(), counter("Joe", 100, returned), counter("Ann", 100, returned))
+	if _, err := seq.Next(); err != nil {
+		t.Fatal(err)
+	}
+	seq.Close() // waits for the producers
+	seq.Close()
+	if len(returned) != 2 {
+		t.Errorf("%d producers returned, want 2", len(returned))
+	}
+	if _, err := seq.Next(); err != sequence.ErrClosed {
+		t.Errorf("Next after Close = %v, want ErrClosed", err)
+	}
+}
+
+func TestContextStopsProducers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	returned := make(chan string, 2)
+	seq := sequence.New(ctx, counter("Joe", 100, returned), counter("Ann", 100, returned))
+	defer seq.Close()
+	if _, err := seq.Next(); err != nil {
+		t.Fatal(err)
+	}
+	// Without another Next or a Close, the producers are left waiting
+	// for their acks until ctx is done.
+	cancel()
+	for i := 0; i < 2; i++ {
+		select {
+		case <-returned:
+		case <-time.After(time.Second):
+			t.Fatalf("%d producers returned when ctx was done, want 2", i)
+		}
+	}
+	if _, err := seq.Next(); err != context.Canceled {
+		t.Errorf("Next after ctx is done = %v, want context.Canceled", err)
+	}
+}
@@ -3,8 +3,14 @@ package main
 import (
 	"fmt"
 	"time"
+
+	"1-basic/clock"
 )
 
+// clk paces the players and the length of the game.
+// The test counts the hits of a player on a clock.Fake.
+var clk = clock.Real
+
 type Ball struct {
 	// Global count
 	hits int
@@ -21,7 +27,7 @@ func main() {
 	// Comment out the line below to cause deadlock.
 	table <- new(Ball)
 	// Main program waits for 1s before exit.
-	time.Sleep(1 * time.Second)
+	clk.Sleep(1 * time.Second)
 	// Game over...
 	// Snatch the ball.
 	<-table
@@ -33,7 +39,7 @@ func player(name string, table chan *Ball) {
 		ball := <-table
 		ball.hits++
 		fmt.Println(name, ball.hits)
-		time.Sleep(100 * time.Millisecond)
+		clk.Sleep(100 * time.Millisecond)
 		// Send the ball back to the adversary.
 		table <- ball
 	}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"1-basic/clock"
+)
+
+func TestPlayerHits(t *testing.T) {
+	fake := clock.NewFake(time.Unix(0, 0))
+	clk = fake
+	table := make(chan *Ball)
+	go player("ping", table)
+	ball := new(Ball)
+	for hits := 1; hits <= 3; hits++ {
+		table <- ball
+		fake.BlockUntil(1) // the player holds the ball
+		start := fake.Now()
+		fake.Advance(100 * time.Millisecond)
+		ball = <-table
+		if ball.hits != hits {
+			t.Fatalf("ball has %d hits, want %d", ball.hits, hits)
+		}
+		if held := fake.Since(start); held != 100*time.Millisecond {
+			t.Errorf("player held the ball for %v, want 100ms", held)
+		}
+	}
+}
@@ -2,404 +2,33 @@ package main
 
 import (
 	"fmt"
-	"math/rand"
 	"time"
-)
-
-type Item struct {
-	Title, Channel, GUID string // a subset of RSS fields
-}
-
-// Fetcher fetches Items and returns the time when the next
-// fetch should be attempted. On failure, Fetch returns an error.
-type Fetcher interface {
-	Fetch() (items []Item, next time.Time, err error)
-}
-
-type Subscription interface {
-	Updates() <-chan Item // stream of Items
-	Close() error         // shuts down the stream
-}
-
-// Subscribe converts Fetchers to a stream.
-func Subscribe(fetcher Fetcher) Subscription {
-	s := &sub{
-		fetcher: fetcher,
-		updates: make(chan Item), // for Updates
-	}
-	go s.loop()
-	return s
-}
-
-// sub implements the Subscription interface.
-type sub struct {
-	fetcher Fetcher         // fetches items
-	updates chan Item       // delivers items to the user
-	closing chan chan error // Close communicates with loop via s.closing
-}
-
-// loopCloseOnly is a version of loop that includes only the logic
-// that handles Close.
-func (s *sub) loopCloseOnly() {
-	var err error // set when Fetch fails
-	for {
-		select {
-		// loop handles Close by replying with the Fetch error and exiting.
-		case errc := <-s.closing:
-			errc <- err
-			close(s.updates) // tells receiver we're done
-			return
-		}
-	}
-}
-
-// loopFetchOnly is a version of loop that includes only the logic
-// that calls Fetch.
-func (s *sub) loopFetchOnly() {
-	var pending []Item // appended by fetch; consumed by send
-	var next time.Time // initially January 1, year 0
-	var err error
-	for {
-		var fetchDelay time.Duration // initially 0 (no delay)
-		if now := time.Now(); next.After(now) {
-			fetchDelay = next.Sub(now)
-		}
-		startFetch := time.After(fetchDelay)
-		select {
-		case <-startFetch:
-			var fetched []Item
-			fetched, next, err = s.fetcher.Fetch()
-			if err != nil {
-				next = time.Now().Add(10 * time.Second)
-				break
-			}
-			pending = append(pending, fetched...)
-		}
-	}
-}
-
-// loopSendOnly is a version of loop that includes only the logic
-// for sending items to s.updates.
-func (s *sub) loopSendOnly() {
-	var pending []Item // appended by fetch; consumed by send
-	for {
-		// Enable send only when pending is non-empty.
-		var first Item
-		var updates chan Item
-		if len(pending) > 0 {
-			first = pending[0]
-			updates = s.updates // enable send case
-		}
-		select {
-		case updates <- first:
-			pending = pending[1:]
-		}
-	}
-}
-
-// loopCombined puts the three cases together.
-// All three cases interact via err, next, and pending.
-// No locks, no condition variables, no callbacks.
-func (s *sub) loopCombined() {
-	var pending []Item
-	var next time.Time
-	var err error
-	for {
-		var fetchDelay time.Duration
-		if now := time.Now(); next.After(now) {
-			fetchDelay = next.Sub(now)
-		}
-		startFetch := time.After(fetchDelay)
-
-		var first Item
-		var updates chan Item
-		if len(pending) > 0 {
-			first = pending[0]
-			updates = s.updates
-		}
-
-		select {
-		case errc := <-s.closing:
-			errc <- err
-			close(s.updates)
-			return
-		case <-startFetch:
-			var fetched []Item
-			fetched, next, err = s.fetcher.Fetch()
-			if err != nil {
-				next = time.Now().Add(10 * time.Second)
-				break
-			}
-			pending = append(pending, fetched...)
-		case updates <- first:
-			pending = pending[1:]
-		}
-	}
-}
-
-// loopDeduplicated extends the loopCombined with de-duplicating of fetched items.
-func (s *sub) loopDeduplicated() {
-	var pending []Item
-	var next time.Time
-	var err error
-	var seen = make(map[string]bool) // set of item.GUIDs
-	for {
-		var fetchDelay time.Duration
-		if now := time.Now(); next.After(now) {
-			fetchDelay = next.Sub(now)
-		}
-		startFetch := time.After(fetchDelay)
-
-		var first Item
-		var updates chan Item
-		if len(pending) > 0 {
-			first = pending[0]
-			updates = s.updates
-		}
-
-		select {
-		case errc := <-s.closing:
-			errc <- err
-			close(s.updates)
-			return
-		case <-startFetch:
-			var fetched []Item
-			fetched, next, err = s.fetcher.Fetch()
-			if err != nil {
-				next = time.Now().Add(10 * time.Second)
-				break
-			}
-			for _, item := range fetched {
-				if !seen[item.GUID] {
-					pending = append(pending, item)
-					seen[item.GUID] = true
-				}
-			}
-		case updates <- first:
-			pending = pending[1:]
-		}
-	}
-}
-
-// loopMaxPending is a loop version that based on loopDeduplicated
-// disabling fetch case when too much pending
-func (s *sub) loopLimitedPending() {
-	const maxPending = 10
-	var pending []Item
-	var next time.Time
-	var err error
-	var seen = make(map[string]bool) // set of item.GUIDs
-	for {
-		var fetchDelay time.Duration
-		if now := time.Now(); next.After(now) {
-			fetchDelay = next.Sub(now)
-		}
-		var startFetch <-chan time.Time
-		if len(pending) < maxPending {
-			startFetch = time.After(fetchDelay) // enable fetch case
-		}
-
-		var first Item
-		var updates chan Item
-		if len(pending) > 0 {
-			first = pending[0]
-			updates = s.updates
-		}
-
-		select {
-		case errc := <-s.closing:
-			errc <- err
-			close(s.updates)
-			return
-		case <-startFetch:
-			var fetched []Item
-			fetched, next, err = s.fetcher.Fetch()
-			if err != nil {
-				next = time.Now().Add(10 * time.Second)
-				break
-			}
-			for _, item := range fetched {
-				if !seen[item.GUID] {
-					pending = append(pending, item)
-					seen[item.GUID] = true
-				}
-			}
-		case updates <- first:
-			pending = pending[1:]
-		}
-	}
-}
-
-// loop is the final version which runs Fetch asynchronously.
-func (s *sub) loop() {
-	const maxPending = 10
-
-	type fetchResult struct {
-		fetched []Item
-		next    time.Time
-		err     error
-	}
-	var fetchDone chan fetchResult // if non-nil, Fetch is running
-
-	var pending []Item
-	var next time.Time
-	var err error
-	var seen = make(map[string]bool) // set of item.GUIDs
-	for {
-		var fetchDelay time.Duration
-		if now := time.Now(); next.After(now) {
-			fetchDelay = next.Sub(now)
-		}
-		var startFetch <-chan time.Time
-		if fetchDone == nil && len(pending) < maxPending {
-			startFetch = time.After(fetchDelay) // enable fetch case
-		}
-
-		var first Item
-		var updates chan Item
-		if len(pending) > 0 {
-			first = pending[0]
-			updates = s.updates
-		}
-
-		select {
-		case <-startFetch:
-			fetchDone = make(chan fetchResult, 1)
-			go func() {
-				fetched, next, err := s.fetcher.Fetch()
-				fetchDone <- fetchResult{fetched, next, err}
-			}()
-		case result := <-fetchDone:
-			fetchDone = nil
-			fetched := result.fetched
-			next, err = result.next, result.err
-			if err != nil {
-				next = time.Now().Add(10 * time.Second)
-				break
-			}
-			for _, item := range fetched {
-				if !seen[item.GUID] {
-					pending = append(pending, item)
-					seen[item.GUID] = true
-				}
-			}
-		case updates <- first:
-			pending = pending[1:]
-
-		case errc := <-s.closing:
-			errc <- err
-			close(s.updates)
-			return
-		}
-	}
-}
-
-type merge struct {
-	subs    []Subscription
-	updates chan Item
-	quit    chan struct{}
-	errs    chan error
-}
-
-// Updates implements the Subscription interface.
-func (s *sub) Updates() <-chan Item {
-	return s.updates
-}
-
-// Close implements the Subscription interface.
-// Close asks loop to exit and waits for a response.
-func (s *sub) Close() error {
-	errc := make(chan error)
-	s.closing <- errc
-	return <-errc
-}
 
-func Merge(subs ...Subscription) Subscription {
-	m := &merge{
-		subs:    subs,
-		updates: make(chan Item),
-		quit:    make(chan struct{}),
-		errs:    make(chan error),
-	}
-	for _, sub := range subs {
-		go func(s Subscription) {
-			for {
-				var it Item
-				select {
-				case it = <-s.Updates():
-				case <-m.quit:
-					m.errs <- s.Close()
-					return
-				}
-				select {
-				case m.updates <- it:
-				case <-m.quit:
-					m.errs <- s.Close()
-					return
-				}
-			}
-		}(sub)
-	}
-	return m
-}
-
-func (m *merge) Updates() <-chan Item {
-	return m.updates
-}
-
-func (m *merge) Close() (err error) {
-	close(m.quit)
-	for range m.subs {
-		if e := <-m.errs; e != nil {
-			err = e
-		}
-	}
-	close(m.updates)
-	return
-}
-
-func Fetch(domain string) Fetcher {
-	return fakeFetch(domain)
-}
-
-func fakeFetch(domain string) Fetcher {
-	return &fakeFetcher{channel: domain}
-}
-
-type fakeFetcher struct {
-	channel string
-	items   []Item
-}
+	"1-basic/clock"
+	"2-advanced/subscription"
+	"2-advanced/subscription/feedtest"
+)
 
-// FakeDuplicates causes the fake fetcher to return duplicated items.
-var FakeDuplicates bool
+// clk schedules the fake feeds and the subscriptions; the test runs
+// them on a clock.Fake.
+var clk = clock.Real
 
-func (f *fakeFetcher) Fetch() (items []Item, next time.Time, err error) {
-	now := time.Now()
-	next = now.Add(time.Duration(rand.Intn(5)) * 500 * time.Millisecond)
-	item := Item{
-		Channel: f.channel,
-		Title:   fmt.Sprintf("Item %d", len(f.items)),
-	}
-	item.GUID = item.Channel + "/" + item.Title
-	f.items = append(f.items, item)
-	if FakeDuplicates {
-		items = f.items
-	} else {
-		items = []Item{item}
-	}
-	return
+// subscribe subscribes to a fake feed for domain, running on clk.
+func subscribe(domain string) subscription.Subscription {
+	f := feedtest.Fetch(domain)
+	f.Clock = clk
+	return subscription.Subscribe(f, subscription.WithClock(clk))
 }
 
 func main() {
 	// Subscribe to some feeds, and create a merged update stream.
-	merged := Merge(
-		Subscribe(Fetch("blog.golang.org")),
-		Subscribe(Fetch("googleblog.blogspot.com")),
-		Subscribe(Fetch("googledevelopers.blogspot.com")),
+	merged := subscription.Merge(
+		subscribe("blog.golang.org"),
+		subscribe("googleblog.blogspot.com"),
+		subscribe("googledevelopers.blogspot.com"),
 	)
 	// Close the subscriptions after some time.
-	time.AfterFunc(3*time.Second, func() {
+	clk.AfterFunc(3*time.Second, func() {
 		fmt.Println("closed: ", merged.Close())
 	})
 	// Print the stream.
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"1-basic/clock"
+	"2-advanced/subscription"
+)
+
+func TestSubscribeOnClock(t *testing.T) {
+	fake := clock.NewFake(time.Unix(0, 0))
+	clk = fake
+	start := fake.Now()
+	merged := subscription.Merge(subscribe("a.example"), subscribe("b.example"))
+	// The first fetches are due at once; the fake clock needs no advance.
+	got := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		it := <-merged.Updates()
+		if it.Title != "Item 0" || !it.Published.Equal(start) {
+			t.Errorf("update %q from %s published %v, want Item 0 published %v", it.Title, it.Channel, it.Published, start)
+		}
+		got[it.Channel] = true
+	}
+	if !got["a.example"] || !got["b.example"] {
+		t.Errorf("updates came from %v, want both feeds", got)
+	}
+	if err := merged.Close(); err != nil {
+		t.Errorf("Close = %v, want nil", err)
+	}
+}
@@ -1,3 +1,7 @@
 module 2-advanced
 
-go 1.18
\ No newline at end of file
+go 1.23
+
+require 1-basic v0.0.0
+
+replace 1-basic => ../1-basic
@@ -0,0 +1,239 @@
+package subscription
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"1-basic/clock"
+)
+
+// defaultFeedInterval is how long to wait between fetches when the feed
+// gives no hint through its ttl element or the Cache-Control header.
+const defaultFeedInterval = 5 * time.Minute
+
+// FetchURL returns a FetcherContext that fetches the RSS 2.0 or Atom feed at url.
+func FetchURL(url string) FetcherContext {
+	return FetchURLClock(url, clock.Real)
+}
+
+// FetchURLClock is like FetchURL but computes when to fetch next by c.
+// Pass the clock given to WithClock.
+func FetchURLClock(url string, c clock.Clock) FetcherContext {
+	return &feedFetcher{url: url, client: http.DefaultClient, clock: c}
+}
+
+// feedFetcher fetches a real feed over HTTP.
+// It remembers the validators of the last response, so an unchanged feed
+// costs a 304 Not Modified instead of a full download.
+type feedFetcher struct {
+	url          string
+	client       *http.Client
+	clock        clock.Clock   // tells when the next fetch is due
+	etag         string        // ETag of the last response
+	lastModified string        // Last-Modified of the last response
+	ttl          time.Duration // ttl of the last parsed feed
+}
+
+func (f *feedFetcher) String() string {
+	return f.url
+}
+
+func (f *feedFetcher) Fetch(ctx context.Context) (items []Item, next time.Time, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.url, nil)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if f.etag != "" {
+		req.Header.Set("If-None-Match", f.etag)
+	}
+	if f.lastModified != "" {
+		req.Header.Set("If-Modified-Since", f.lastModified)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		// Nothing new; keep the validators we already have.
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		feed, err := parseFeed(body)
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("fetch %s: %w", f.url, err)
+		}
+		items, f.ttl = feed.items, feed.ttl
+		f.etag = resp.Header.Get("ETag")
+		f.lastModified = resp.Header.Get("Last-Modified")
+	case resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests:
+		// The request itself is wrong; asking again will not help.
+		return nil, time.Time{}, Permanent(fmt.Errorf("fetch %s: %s", f.url, resp.Status))
+	default:
+		return nil, time.Time{}, fmt.Errorf("fetch %s: %s", f.url, resp.Status)
+	}
+
+	// Wait for the longer of what the server and the feed ask for.
+	interval := f.ttl
+	if maxAge, ok := cacheMaxAge(resp.Header.Get("Cache-Control")); ok && maxAge > interval {
+		interval = maxAge
+	}
+	if interval <= 0 {
+		interval = defaultFeedInterval
+	}
+	return items, f.clock.Now().Add(interval), nil
+}
+
+// cacheMaxAge returns the max-age directive of a Cache-Control header.
+func cacheMaxAge(header string) (time.Duration, bool) {
+	for _, directive := range strings.Split(header, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		if !strings.EqualFold(name, "max-age") {
+			continue
+		}
+		seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+		if err != nil || seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	return 0, false
+}
+
+// feed is the part of a parsed RSS or Atom document we care about.
+type feed struct {
+	items []Item
+	ttl   time.Duration
+}
+
+// parseFeed parses an RSS 2.0 or Atom document, telling them apart by the root element.
+func parseFeed(data []byte) (feed, error) {
+	var root struct {
+		XMLName xml.Name
+	}
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return feed{}, err
+	}
+	switch root.XMLName.Local {
+	case "rss":
+		return parseRSS(data)
+	case "feed":
+		return parseAtom(data)
+	}
+	return feed{}, fmt.Errorf("unknown feed format <%s>", root.XMLName.Local)
+}
+
+type rssDocument struct {
+	Channel struct {
+		Title string `xml:"title"`
+		TTL   int    `xml:"ttl"` // minutes
+		Items []struct {
+			Title   string `xml:"title"`
+			Link    string `xml:"link"`
+			GUID    string `xml:"guid"`
+			PubDate string `xml:"pubDate"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+func parseRSS(data []byte) (feed, error) {
+	var doc rssDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return feed{}, err
+	}
+	f := feed{ttl: time.Duration(doc.Channel.TTL) * time.Minute}
+	for _, it := range doc.Channel.Items {
+		item := Item{
+			Title:     strings.TrimSpace(it.Title),
+			Channel:   strings.TrimSpace(doc.Channel.Title),
+			GUID:      strings.TrimSpace(it.GUID),
+			Link:      strings.TrimSpace(it.Link),
+			Published: parseTime(it.PubDate),
+		}
+		if item.GUID == "" {
+			item.GUID = item.Link
+		}
+		f.items = append(f.items, item)
+	}
+	return f, nil
+}
+
+type atomDocument struct {
+	Title   string `xml:"title"`
+	Entries []struct {
+		Title     string `xml:"title"`
+		ID        string `xml:"id"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+		Links     []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+	} `xml:"entry"`
+}
+
+func parseAtom(data []byte) (feed, error) {
+	var doc atomDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return feed{}, err
+	}
+	var f feed
+	for _, e := range doc.Entries {
+		item := Item{
+			Title:     strings.TrimSpace(e.Title),
+			Channel:   strings.TrimSpace(doc.Title),
+			GUID:      strings.TrimSpace(e.ID),
+			Published: parseTime(e.Published),
+		}
+		if item.Published.IsZero() {
+			item.Published = parseTime(e.Updated)
+		}
+		// The alternate link (rel missing or "alternate") points at the entry itself.
+		for _, l := range e.Links {
+			if l.Rel == "" || l.Rel == "alternate" {
+				item.Link = l.Href
+				break
+			}
+		}
+		if item.GUID == "" {
+			item.GUID = item.Link
+		}
+		f.items = append(f.items, item)
+	}
+	return f, nil
+}
+
+// timeLayouts are the date formats found in the wild, RFC 822 variants for RSS
+// and RFC 3339 for Atom.
+var timeLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	time.RFC822Z,
+	time.RFC822,
+	time.RFC3339,
+	time.RFC3339Nano,
+}
+
+// parseTime parses a feed date, returning the zero Time if no layout matches.
+func parseTime(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,174 @@
+package subscription
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"1-basic/clock"
+)
+
+// serveFixture returns a server serving the fixture in testdata with the
+// given headers, and counts the requests it answers with 304.
+func serveFixture(t *testing.T, name string, header http.Header) (*httptest.Server, *int) {
+	t.Helper()
+	body, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notModified := new(int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		etag := header.Get("ETag")
+		if etag != "" && r.Header.Get("If-None-Match") == etag {
+			*notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, notModified
+}
+
+func TestFetchURLRSS(t *testing.T) {
+	srv, _ := serveFixture(t, "feed.rss", nil)
+	items, _, err := FetchURL(srv.URL).Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Item{{
+		Title:     "Go Concurrency Patterns",
+		Channel:   "The Go Blog",
+		GUID:      "https://go.dev/blog/concurrency-patterns",
+		Link:      "https://go.dev/talks/2012/concurrency.slide",
+		Published: time.Date(2012, 7, 13, 9, 0, 0, 0, time.UTC),
+	}, {
+		Title:     "Advanced Go Concurrency Patterns",
+		Channel:   "The Go Blog",
+		GUID:      "https://go.dev/talks/2013/advconc.slide", // no guid; the link stands in
+		Link:      "https://go.dev/talks/2013/advconc.slide",
+		Published: time.Date(2013, 6, 7, 9, 0, 0, 0, time.UTC),
+	}}
+	checkItems(t, items, want)
+}
+
+func TestFetchURLAtom(t *testing.T) {
+	srv, _ := serveFixture(t, "feed.atom", nil)
+	items, _, err := FetchURL(srv.URL).Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Item{{
+		Title:     "Go Concurrency Patterns",
+		Channel:   "The Go Blog",
+		GUID:      "tag:blog.golang.org,2013:blog.golang.org/concurrency-patterns",
+		Link:      "https://go.dev/blog/concurrency-patterns", // the alternate link, not self
+		Published: time.Date(2012, 7, 13, 9, 0, 0, 0, time.UTC),
+	}, {
+		Title:     "Advanced Go Concurrency Patterns",
+		Channel:   "The Go Blog",
+		GUID:      "https://go.dev/blog/advanced-go-concurrency-patterns",
+		Link:      "https://go.dev/blog/advanced-go-concurrency-patterns",
+		Published: time.Date(2013, 6, 7, 9, 0, 0, 0, time.UTC), // from updated
+	}}
+	checkItems(t, items, want)
+}
+
+func checkItems(t *testing.T, got, want []Item) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d items, want %d: %v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].Title != want[i].Title || got[i].Channel != want[i].Channel ||
+			got[i].GUID != want[i].GUID || got[i].Link != want[i].Link ||
+			!got[i].Published.Equal(want[i].Published) {
+			t.Errorf("item %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestFetchURLNotModified(t *testing.T) {
+	srv, notModified := serveFixture(t, "feed.rss", http.Header{"Etag": {`"v1"`}})
+	f := FetchURL(srv.URL)
+	items, _, err := f.Fetch(context.Background())
+	if err != nil || len(items) != 2 {
+		t.Fatalf("first Fetch = %d items, %v; want 2 items", len(items), err)
+	}
+	items, next, err := f.Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *notModified != 1 {
+		t.Errorf("server answered %d requests with 304, want 1", *notModified)
+	}
+	if len(items) != 0 {
+		t.Errorf("Fetch of an unchanged feed = %d items, want none", len(items))
+	}
+	// The ttl of the feed parsed before still applies.
+	if d := time.Until(next); d < 9*time.Minute || d > 10*time.Minute {
+		t.Errorf("next fetch in %v, want the feed's ttl of 10m", d)
+	}
+}
+
+func TestFetchURLInterval(t *testing.T) {
+	for _, tt := range []struct {
+		fixture, cacheControl string
+		want                  time.Duration
+	}{
+		{"feed.rss", "", 10 * time.Minute},                    // ttl
+		{"feed.rss", "max-age=60", 10 * time.Minute},          // ttl is longer
+		{"feed.rss", "public, max-age=3600", time.Hour},       // max-age is longer
+		{"feed.atom", "", defaultFeedInterval},                // no hint
+		{"feed.atom", "max-age=120", 2 * time.Minute},         // max-age only
+		{"feed.atom", `max-age="bogus"`, defaultFeedInterval}, // unparsable
+	} {
+		var header http.Header
+		if tt.cacheControl != "" {
+			header = http.Header{"Cache-Control": {tt.cacheControl}}
+		}
+		srv, _ := serveFixture(t, tt.fixture, header)
+		clk := clock.NewFake(time.Unix(0, 0))
+		_, next, err := FetchURLClock(srv.URL, clk).Fetch(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d := next.Sub(clk.Now()); d != tt.want {
+			t.Errorf("%s with Cache-Control %q: next fetch in %v, want %v", tt.fixture, tt.cacheControl, d, tt.want)
+		}
+	}
+}
+
+func TestFetchURLStatus(t *testing.T) {
+	for _, tt := range []struct {
+		status    int
+		permanent bool
+	}{
+		{http.StatusNotFound, true},
+		{http.StatusGone, true},
+		{http.StatusForbidden, true},
+		{http.StatusRequestTimeout, false},
+		{http.StatusTooManyRequests, false},
+		{http.StatusInternalServerError, false},
+		{http.StatusServiceUnavailable, false},
+	} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+		}))
+		_, _, err := FetchURL(srv.URL).Fetch(context.Background())
+		srv.Close()
+		if err == nil {
+			t.Errorf("status %d: Fetch succeeded", tt.status)
+			continue
+		}
+		if IsPermanent(err) != tt.permanent {
+			t.Errorf("status %d: IsPermanent(%v) = %v, want %v", tt.status, err, !tt.permanent, tt.permanent)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// Package feedtest provides a fake subscription.Fetcher, for tests and
+// demos that must not touch the network. Together with a clock.Fake it
+// lets tests step a subscription through time deterministically.
+package feedtest
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sync"
+	"time"
+
+	"1-basic/clock"
+	"2-advanced/subscription"
+)
+
+// Fetcher is a fake subscription.Fetcher that makes up a new item on every Fetch.
+// Its fields must be set before the first Fetch.
+type Fetcher struct {
+	Channel string // the Channel of every item
+
+	// Interval is how long after a Fetch the next one should be attempted.
+	// Zero means a random interval of half a second to 2 seconds,
+	// drawn from a source seeded with Seed.
+	Interval time.Duration
+	Seed     int64
+
+	// Duplicates makes Fetch return every item made up so far,
+	// not just the new one.
+	Duplicates bool
+
+	// Err, if set, is returned by every Fetch instead of a new item.
+	Err error
+
+	// Clock is where the Fetcher reads the time; nil means clock.Real.
+	// Share a clock.Fake with subscription.WithClock to control both.
+	Clock clock.Clock
+
+	mu      sync.Mutex
+	rand    *rand.Rand // for the random Interval; made on first use
+	items   []subscription.Item
+	fetches int
+}
+
+// Fetch returns a Fetcher for domain with a random interval,
+// like the fake in the talk. The intervals are seeded by domain,
+// so they are the same on every run.
+func Fetch(domain string) *Fetcher {
+	h := fnv.New64a()
+	h.Write([]byte(domain))
+	return &Fetcher{Channel: domain, Seed: int64(h.Sum64())}
+}
+
+func (f *Fetcher) String() string {
+	return f.Channel
+}
+
+// Fetch implements the subscription.Fetcher interface.
+func (f *Fetcher) Fetch() (items []subscription.Item, next time.Time, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fetches++
+	clk := f.Clock
+	if clk == nil {
+		clk = clock.Real
+	}
+	now := clk.Now()
+	interval := f.Interval
+	if interval == 0 {
+		if f.rand == nil {
+			f.rand = rand.New(rand.NewSource(f.Seed))
+		}
+		interval = time.Duration(1+f.rand.Intn(4)) * 500 * time.Millisecond
+	}
+	next = now.Add(interval)
+	if f.Err != nil {
+		return nil, next, f.Err
+	}
+	item := subscription.Item{
+		Channel:   f.Channel,
+		Title:     fmt.Sprintf("Item %d", len(f.items)),
+		Published: now,
+	}
+	item.GUID = item.Channel + "/" + item.Title
+	f.items = append(f.items, item)
+	if f.Duplicates {
+		items = append(items, f.items...)
+	} else {
+		items = []subscription.Item{item}
+	}
+	return
+}
+
+// Fetches returns how many times Fetch was called.
+func (f *Fetcher) Fetches() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.fetches
+}
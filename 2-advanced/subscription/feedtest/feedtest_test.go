@@ -0,0 +1,24 @@
+package feedtest
+
+import (
+	"testing"
+	"time"
+
+	"1-basic/clock"
+)
+
+func TestRandomIntervalDeterministic(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	a := &Fetcher{Channel: "a", Seed: 3, Clock: clk}
+	b := &Fetcher{Channel: "b", Seed: 3, Clock: clk}
+	for i := 0; i < 20; i++ {
+		_, nextA, _ := a.Fetch()
+		_, nextB, _ := b.Fetch()
+		if !nextA.Equal(nextB) {
+			t.Fatalf("fetch %d: same seed, different next fetch: %v and %v", i, nextA, nextB)
+		}
+		if d := nextA.Sub(clk.Now()); d < 500*time.Millisecond || d > 2*time.Second {
+			t.Fatalf("fetch %d: interval %v, want between 500ms and 2s", i, d)
+		}
+	}
+}
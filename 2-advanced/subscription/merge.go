@@ -0,0 +1,289 @@
+package subscription
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrClosed is returned when adding to or removing from a merged subscription
+// that was closed.
+var ErrClosed = errors.New("subscription closed")
+
+// ErrNotMerged is returned when removing a subscription that is not merged.
+var ErrNotMerged = errors.New("subscription not merged")
+
+// MergedSubscription is a Subscription whose inputs can be added
+// and removed while it runs. Its Updates channel stays open until Close.
+type MergedSubscription interface {
+	Subscription
+	Add(s Subscription) error    // starts merging the updates of s
+	Remove(s Subscription) error // stops merging s, closes it and returns its error
+}
+
+// SourceError is an error from one of the subscriptions of a merge.
+type SourceError struct {
+	Source Subscription // the subscription that failed
+	Err    error
+}
+
+func (e *SourceError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Source, e.Err)
+}
+
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// MergeError is returned by Close and Err of a merged subscription
+// when some of its subscriptions failed. errors.Is and errors.As
+// look through every SourceError it holds.
+type MergeError struct {
+	Errs []*SourceError
+}
+
+func (e *MergeError) Error() string {
+	msgs := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (e *MergeError) Unwrap() []error {
+	errs := make([]error, len(e.Errs))
+	for i, err := range e.Errs {
+		errs[i] = err
+	}
+	return errs
+}
+
+// mergeError returns errs as a *MergeError, or nil if there are none.
+func mergeError(errs []*SourceError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &MergeError{Errs: errs}
+}
+
+type merge struct {
+	updates chan Item
+	errors  chan error // non-fatal errors, as *SourceError
+	done    chan struct{}
+
+	mu     sync.Mutex              // guards subs and closed
+	subs   map[Subscription]*input // merged subscriptions
+	closed bool                    // set by Close; no more Adds
+	err    error                   // returned by Close, set before done is closed
+	added  int                     // number of Adds, for input.seq
+	wg     sync.WaitGroup          // forwarding goroutines still running
+
+	closeOnce sync.Once
+}
+
+// input is the link between a merged subscription and its forwarding goroutine.
+type input struct {
+	seq  int           // order of Add, to report errors in a stable order
+	quit chan struct{} // closed by Remove or Close to stop forwarding
+	errs chan error    // receives the subscription's Close error
+}
+
+// Merge merges the updates of subs into one stream.
+func Merge(subs ...Subscription) MergedSubscription {
+	m := &merge{
+		updates: make(chan Item),
+		errors:  make(chan error),
+		done:    make(chan struct{}),
+		subs:    make(map[Subscription]*input),
+	}
+	for _, s := range subs {
+		m.Add(s)
+	}
+	return m
+}
+
+// Add implements the MergedSubscription interface.
+// Adding a subscription that is already merged does nothing.
+func (m *merge) Add(s Subscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrClosed
+	}
+	if _, ok := m.subs[s]; ok {
+		return nil
+	}
+	m.added++
+	in := &input{
+		seq:  m.added,
+		quit: make(chan struct{}),
+		errs: make(chan error, 1), // so forward never waits for the reader
+	}
+	m.subs[s] = in
+	m.wg.Add(1)
+	go m.forward(s, in)
+	return nil
+}
+
+// forward sends the updates and errors of s on m.updates and m.errors
+// until in.quit is closed. Like sub.loop, it enables each case only when
+// it has something to do.
+func (m *merge) forward(s Subscription, in *input) {
+	defer m.wg.Done()
+	recv, errc := s.Updates(), s.Errors()
+	var it Item
+	var updates chan Item // non-nil while it waits to be sent
+	var report error      // latest error of s, not yet sent
+	for {
+		if updates == nil && recv == nil && errc == nil && report == nil {
+			// s shut down on its own and everything is sent; wait to be removed.
+			<-in.quit
+			in.errs <- s.Close()
+			return
+		}
+		pending := recv
+		if updates != nil {
+			pending = nil // one item at a time
+		}
+		var errs chan error
+		if report != nil {
+			errs = m.errors
+		}
+		select {
+		case item, ok := <-pending:
+			if !ok {
+				recv = nil
+				if err := s.Err(); err != nil {
+					report = &SourceError{s, err}
+				}
+				break
+			}
+			it, updates = item, m.updates
+		case updates <- it:
+			updates = nil
+		case err, ok := <-errc:
+			if !ok {
+				errc = nil
+				break
+			}
+			report = &SourceError{s, err}
+		case errs <- report:
+			report = nil
+		case <-in.quit:
+			in.errs <- s.Close()
+			return
+		}
+	}
+}
+
+// Remove implements the MergedSubscription interface.
+func (m *merge) Remove(s Subscription) error {
+	m.mu.Lock()
+	closed := m.closed
+	in, ok := m.subs[s]
+	delete(m.subs, s)
+	m.mu.Unlock()
+	switch {
+	case closed:
+		return ErrClosed
+	case !ok:
+		return ErrNotMerged
+	}
+	close(in.quit)
+	return <-in.errs
+}
+
+func (m *merge) Updates() <-chan Item {
+	return m.updates
+}
+
+// Errors returns the errors of the merged subscriptions as they happen,
+// each as a *SourceError. Only the latest error of each is kept for a slow reader.
+func (m *merge) Errors() <-chan error {
+	return m.errors
+}
+
+// Close closes every merged subscription and returns a *MergeError
+// holding the errors of those that failed. Like sub.Close, only the first
+// call returns an error; later calls wait for the first to finish.
+func (m *merge) Close() (err error) {
+	m.closeOnce.Do(func() {
+		m.mu.Lock()
+		m.closed = true
+		subs := m.subs
+		m.subs = nil
+		m.mu.Unlock()
+
+		for _, in := range subs {
+			close(in.quit)
+		}
+		var errs []*SourceError
+		for _, s := range sortInputs(subs) {
+			if e := <-subs[s].errs; e != nil {
+				errs = append(errs, &SourceError{s, e})
+			}
+		}
+		err = mergeError(errs)
+		m.err = err
+		m.wg.Wait() // forwarders of removed subscriptions, too
+		close(m.updates)
+		close(m.errors)
+		close(m.done)
+	})
+	<-m.done
+	return
+}
+
+func (m *merge) Done() <-chan struct{} {
+	return m.done
+}
+
+// inputs returns the currently merged subscriptions in the order they were added.
+func (m *merge) inputs() []Subscription {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return sortInputs(m.subs)
+}
+
+// sortInputs returns the subscriptions of subs in the order they were added.
+func sortInputs(subs map[Subscription]*input) []Subscription {
+	sorted := make([]Subscription, 0, len(subs))
+	for s := range subs {
+		sorted = append(sorted, s)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return subs[sorted[i]].seq < subs[sorted[j]].seq
+	})
+	return sorted
+}
+
+// Stats returns the sum of the merged subscriptions' Stats.
+func (m *merge) Stats() (st Stats) {
+	for _, s := range m.inputs() {
+		sst := s.Stats()
+		st.Dropped += sst.Dropped
+		st.Spilled += sst.Spilled
+	}
+	return st
+}
+
+// Err returns a *MergeError holding the current errors of the merged subscriptions.
+// After Close, it returns what Close returned.
+func (m *merge) Err() error {
+	m.mu.Lock()
+	closed := m.closed
+	m.mu.Unlock()
+	if closed {
+		<-m.done
+		return m.err
+	}
+	var errs []*SourceError
+	for _, s := range m.inputs() {
+		if err := s.Err(); err != nil {
+			errs = append(errs, &SourceError{s, err})
+		}
+	}
+	return mergeError(errs)
+}
@@ -0,0 +1,137 @@
+package subscription_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"1-basic/clock"
+	"2-advanced/subscription"
+	"2-advanced/subscription/feedtest"
+)
+
+// subscribe subscribes to a feedtest Fetcher of channel on clk.
+func subscribe(clk clock.Clock, channel string) subscription.Subscription {
+	f := &feedtest.Fetcher{Channel: channel, Interval: time.Second, Clock: clk}
+	return subscription.Subscribe(f, subscription.WithClock(clk))
+}
+
+func TestMergeAddRemove(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	a, b := subscribe(clk, "a"), subscribe(clk, "b")
+	m := subscription.Merge(a)
+	defer m.Close()
+	if item := <-m.Updates(); item.Channel != "a" {
+		t.Fatalf("got an item from %q, want a", item.Channel)
+	}
+
+	if err := m.Add(b); err != nil {
+		t.Fatalf("Add(b) = %v", err)
+	}
+	if err := m.Add(b); err != nil {
+		t.Errorf("Add(b) again = %v, want nil", err)
+	}
+	if item := <-m.Updates(); item.Channel != "b" {
+		t.Fatalf("got an item from %q, want b", item.Channel)
+	}
+
+	if err := m.Remove(a); err != nil {
+		t.Errorf("Remove(a) = %v", err)
+	}
+	if _, ok := <-a.Updates(); ok {
+		t.Error("Remove did not close a")
+	}
+	if err := m.Remove(a); err != subscription.ErrNotMerged {
+		t.Errorf("Remove(a) again = %v, want ErrNotMerged", err)
+	}
+	if err := m.Add(a); err != nil {
+		t.Errorf("re-Add(a) = %v", err)
+	}
+	if err := m.Remove(a); err != nil {
+		t.Errorf("Remove(a) after re-Add = %v, want nil", err)
+	}
+	if err := m.Remove(b); err != nil {
+		t.Errorf("Remove(b) = %v", err)
+	}
+
+	// With nothing merged, Updates stays open until Close.
+	select {
+	case item, ok := <-m.Updates():
+		t.Fatalf("Updates with nothing merged = %v, %v", item, ok)
+	case <-time.After(10 * time.Millisecond):
+	}
+	c := subscribe(clk, "c")
+	if err := m.Add(c); err != nil {
+		t.Fatalf("Add(c) = %v", err)
+	}
+	if item := <-m.Updates(); item.Channel != "c" {
+		t.Errorf("got an item from %q, want c", item.Channel)
+	}
+}
+
+func TestMergeClosed(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	a := subscribe(clk, "a")
+	m := subscription.Merge(a)
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close = %v", err)
+	}
+	if _, ok := <-m.Updates(); ok {
+		t.Error("Updates still open after Close")
+	}
+	b := subscribe(clk, "b")
+	defer b.Close()
+	if err := m.Add(b); !errors.Is(err, subscription.ErrClosed) {
+		t.Errorf("Add after Close = %v, want ErrClosed", err)
+	}
+	if err := m.Remove(a); !errors.Is(err, subscription.ErrClosed) {
+		t.Errorf("Remove after Close = %v, want ErrClosed", err)
+	}
+}
+
+func TestMergeError(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	errA, errB := errors.New("a failed"), errors.New("b failed")
+	failing := func(channel string, err error) subscription.Subscription {
+		f := &feedtest.Fetcher{Channel: channel, Interval: time.Second, Err: err, Clock: clk}
+		return subscription.Subscribe(f, subscription.WithClock(clk))
+	}
+	a, b := failing("a", errA), failing("b", errB)
+	m := subscription.Merge(a, b)
+
+	// Each failure shows up on Errors as a *SourceError.
+	got := make(map[error]subscription.Subscription)
+	for i := 0; i < 2; i++ {
+		var se *subscription.SourceError
+		if err := <-m.Errors(); !errors.As(err, &se) {
+			t.Fatalf("Errors delivered %v, want a *SourceError", err)
+		}
+		got[se.Err] = se.Source
+	}
+	if got[errA] != a || got[errB] != b {
+		t.Errorf("Errors delivered %v, want %v from a and %v from b", got, errA, errB)
+	}
+
+	err := m.Close()
+	var me *subscription.MergeError
+	if !errors.As(err, &me) {
+		t.Fatalf("Close = %v, want a *MergeError", err)
+	}
+	if len(me.Errs) != 2 || me.Errs[0].Source != a || me.Errs[1].Source != b {
+		t.Errorf("MergeError holds %v, want the errors of a and b in order", me.Errs)
+	}
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Errorf("errors.Is(%v) misses %v or %v", err, errA, errB)
+	}
+	var se *subscription.SourceError
+	if !errors.As(err, &se) || se.Err != errA {
+		t.Errorf("errors.As(%v) = %v, want the SourceError of a", err, se)
+	}
+	if want := fmt.Sprintf("a: %v\nb: %v", errA, errB); err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+	if err2 := m.Err(); err2 != err {
+		t.Errorf("Err after Close = %v, want %v", err2, err)
+	}
+}
@@ -0,0 +1,218 @@
+package subscription
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"sync/atomic"
+)
+
+// OverflowPolicy says what a subscription does with fetched items
+// when its pending buffer is full because the consumer is slow.
+type OverflowPolicy int
+
+const (
+	BlockFetch  OverflowPolicy = iota // stop fetching until the consumer catches up
+	DropOldest                        // keep fetching and discard the oldest pending item
+	DropNewest                        // keep fetching and discard the newly fetched item
+	SpillToDisk                       // keep fetching and queue the excess in a temporary file
+)
+
+// defaultMaxPending is the default size of the pending buffer.
+const defaultMaxPending = 10
+
+// Stats counts what a subscription did with items it could not buffer.
+type Stats struct {
+	Dropped uint64 // items discarded by DropOldest or DropNewest, or lost by a failed spill or read-back
+	Spilled uint64 // items written to disk by SpillToDisk
+}
+
+func (st *Stats) load() Stats {
+	return Stats{
+		Dropped: atomic.LoadUint64(&st.Dropped),
+		Spilled: atomic.LoadUint64(&st.Spilled),
+	}
+}
+
+// pendingQueue holds the items fetched but not yet sent, oldest first.
+// It belongs to sub.loop, so it needs no locking; only stats is shared.
+type pendingQueue struct {
+	max      int
+	overflow OverflowPolicy
+	spillDir string
+	stats    *Stats
+
+	items   []Item          // in memory
+	spill   *spillFile      // items beyond max, queued after items
+	spilled []string        // GUIDs of the items in spill, oldest first
+	guids   map[string]bool // GUIDs of all the items in q
+}
+
+// Has reports whether q holds an item with the given GUID.
+func (q *pendingQueue) Has(guid string) bool {
+	return q.guids[guid]
+}
+
+func (q *pendingQueue) Len() int {
+	n := len(q.items)
+	if q.spill != nil {
+		n += q.spill.len()
+	}
+	return n
+}
+
+// Full reports whether q holds as many items as it may keep in memory.
+func (q *pendingQueue) Full() bool {
+	return len(q.items) >= q.max
+}
+
+func (q *pendingQueue) Front() Item {
+	return q.items[0]
+}
+
+// Pop removes the front item, refilling memory from the spill file.
+// Spilled items that cannot be read back are counted as dropped.
+func (q *pendingQueue) Pop() error {
+	delete(q.guids, q.items[0].GUID)
+	q.items = q.items[1:]
+	var err error
+	for q.spill != nil && q.spill.len() > 0 {
+		item, rerr := q.spill.read()
+		guid := q.spilled[0]
+		q.spilled = q.spilled[1:]
+		if rerr != nil {
+			delete(q.guids, guid)
+			atomic.AddUint64(&q.stats.Dropped, 1)
+			err = rerr
+			continue // q.items may be empty while the spill is not
+		}
+		q.items = append(q.items, item)
+		break
+	}
+	return err
+}
+
+// Push adds item to the back of q, applying the overflow policy when q is full.
+func (q *pendingQueue) Push(item Item) error {
+	switch {
+	case q.overflow == SpillToDisk && (q.Full() || q.Len() > len(q.items)):
+		// Once anything is spilled, later items must queue behind it on disk.
+		if q.spill == nil {
+			spill, err := newSpillFile(q.spillDir)
+			if err != nil {
+				atomic.AddUint64(&q.stats.Dropped, 1)
+				return err
+			}
+			q.spill = spill
+		}
+		if err := q.spill.write(item); err != nil {
+			atomic.AddUint64(&q.stats.Dropped, 1)
+			return err
+		}
+		q.spilled = append(q.spilled, item.GUID)
+		atomic.AddUint64(&q.stats.Spilled, 1)
+	case q.overflow == DropNewest && q.Full():
+		atomic.AddUint64(&q.stats.Dropped, 1)
+		return nil
+	case q.overflow == DropOldest && q.Full():
+		delete(q.guids, q.items[0].GUID)
+		q.items = append(q.items[1:], item)
+		atomic.AddUint64(&q.stats.Dropped, 1)
+	default:
+		q.items = append(q.items, item)
+	}
+	if q.guids == nil {
+		q.guids = make(map[string]bool)
+	}
+	q.guids[item.GUID] = true
+	return nil
+}
+
+// Close removes the spill file, if any.
+func (q *pendingQueue) Close() error {
+	if q.spill == nil {
+		return nil
+	}
+	return q.spill.close()
+}
+
+// spillFile is a FIFO of Items in a temporary file, one JSON object per line.
+// It is truncated whenever it drains, so it only grows while the consumer lags.
+type spillFile struct {
+	f        *os.File
+	r        *bufio.Reader
+	writeOff int64
+	written  int // items written since the last truncation
+	consumed int // items read since the last truncation
+}
+
+func newSpillFile(dir string) (*spillFile, error) {
+	f, err := os.CreateTemp(dir, "subscription-spill-*")
+	if err != nil {
+		return nil, err
+	}
+	s := &spillFile{f: f}
+	s.r = bufio.NewReader(&offsetReader{f: f})
+	return s, nil
+}
+
+func (s *spillFile) len() int {
+	return s.written - s.consumed
+}
+
+func (s *spillFile) write(item Item) error {
+	b, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	if _, err := s.f.WriteAt(b, s.writeOff); err != nil {
+		return err
+	}
+	s.writeOff += int64(len(b))
+	s.written++
+	return nil
+}
+
+// read removes the oldest item. An item that cannot be read is removed too,
+// so that read always makes progress.
+func (s *spillFile) read() (Item, error) {
+	var item Item
+	line, err := s.r.ReadBytes('\n')
+	s.consumed++
+	if s.len() == 0 && s.f.Truncate(0) == nil {
+		// Drained: start over at the beginning of an empty file.
+		s.writeOff, s.written, s.consumed = 0, 0, 0
+		s.r.Reset(&offsetReader{f: s.f})
+	}
+	if err != nil {
+		return item, err
+	}
+	return item, json.Unmarshal(line, &item)
+}
+
+func (s *spillFile) close() error {
+	err := s.f.Close()
+	if rerr := os.Remove(s.f.Name()); err == nil {
+		err = rerr
+	}
+	return err
+}
+
+// offsetReader reads f from an offset of its own, independent of writes.
+// It reports io.EOF only when nothing was read, so the bufio.Reader on top
+// does not hold on to an EOF that later writes make stale.
+type offsetReader struct {
+	f   *os.File
+	off int64
+}
+
+func (r *offsetReader) Read(p []byte) (int, error) {
+	n, err := r.f.ReadAt(p, r.off)
+	r.off += int64(n)
+	if n > 0 && err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
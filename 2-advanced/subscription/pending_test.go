@@ -0,0 +1,71 @@
+package subscription_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"1-basic/clock"
+	"2-advanced/subscription"
+	"2-advanced/subscription/feedtest"
+)
+
+func TestWithPendingZero(t *testing.T) {
+	for _, overflow := range []subscription.OverflowPolicy{
+		subscription.BlockFetch,
+		subscription.DropOldest,
+		subscription.DropNewest,
+		subscription.SpillToDisk,
+	} {
+		clk := clock.NewFake(time.Unix(0, 0))
+		f := &feedtest.Fetcher{Channel: "blog", Interval: time.Second, Clock: clk}
+		s := subscription.Subscribe(f, subscription.WithClock(clk),
+			subscription.WithPending(0, overflow), subscription.WithSpillDir(t.TempDir()))
+		select {
+		case item := <-s.Updates():
+			if item.Title != "Item 0" {
+				t.Errorf("policy %d: got %q, want Item 0", overflow, item.Title)
+			}
+		case <-time.After(5 * time.Second):
+			t.Errorf("policy %d: WithPending(0) delivered nothing", overflow)
+		}
+		s.Close()
+	}
+}
+
+// fetchUnread lets the subscription on clk fetch n more items after the first
+// while nobody reads them.
+func fetchUnread(clk *clock.Fake, n int) {
+	for i := 0; i < n; i++ {
+		<-clk.Blocked(1)
+		clk.Advance(time.Second)
+	}
+	<-clk.Blocked(1) // the last Fetch is done
+}
+
+func TestOverflowPolicies(t *testing.T) {
+	for _, test := range []struct {
+		overflow subscription.OverflowPolicy
+		want     []int // item numbers delivered
+		stats    subscription.Stats
+	}{
+		{subscription.DropOldest, []int{4, 5}, subscription.Stats{Dropped: 4}},
+		{subscription.DropNewest, []int{0, 1}, subscription.Stats{Dropped: 4}},
+		{subscription.SpillToDisk, []int{0, 1, 2, 3, 4, 5}, subscription.Stats{Spilled: 4}},
+	} {
+		clk := clock.NewFake(time.Unix(0, 0))
+		f := &feedtest.Fetcher{Channel: "blog", Interval: time.Second, Clock: clk}
+		s := subscription.Subscribe(f, subscription.WithClock(clk),
+			subscription.WithPending(2, test.overflow), subscription.WithSpillDir(t.TempDir()))
+		fetchUnread(clk, 5)
+		for _, n := range test.want {
+			if item, want := <-s.Updates(), fmt.Sprintf("Item %d", n); item.Title != want {
+				t.Errorf("policy %d: got %q, want %q", test.overflow, item.Title, want)
+			}
+		}
+		s.Close()
+		if got := s.Stats(); got != test.stats {
+			t.Errorf("policy %d: Stats = %+v, want %+v", test.overflow, got, test.stats)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package subscription
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy decides what a subscription does after a failed Fetch.
+type RetryPolicy interface {
+	// Retry is called with the attempt-th consecutive Fetch error.
+	// It returns how long to wait before fetching again,
+	// or false to end the subscription with err.
+	Retry(attempt int, err error) (delay time.Duration, ok bool)
+}
+
+// DefaultRetryPolicy retries every 10 seconds for as long as errors are not permanent.
+var DefaultRetryPolicy RetryPolicy = Backoff{Initial: 10 * time.Second, Max: 10 * time.Second}
+
+// Backoff is a RetryPolicy with exponential backoff and jitter.
+// The delay after the n-th consecutive error is Initial * Multiplier^(n-1),
+// capped at Max and then spread by up to ±Jitter of itself.
+// Permanent errors are never retried.
+type Backoff struct {
+	Initial     time.Duration // delay after the first error
+	Max         time.Duration // upper bound on the delay; 0 means no bound
+	Multiplier  float64       // growth per error; 0 means 2
+	Jitter      float64       // fraction of the delay to randomize, clamped to [0, 1]
+	MaxAttempts int           // give up after this many errors in a row; 0 means never
+}
+
+// Retry implements the RetryPolicy interface.
+func (b Backoff) Retry(attempt int, err error) (time.Duration, bool) {
+	if IsPermanent(err) || (b.MaxAttempts > 0 && attempt >= b.MaxAttempts) {
+		return 0, false
+	}
+	multiplier := b.Multiplier
+	if multiplier == 0 {
+		multiplier = 2
+	}
+	delay := float64(b.Initial) * math.Pow(multiplier, float64(attempt-1))
+	if b.Max > 0 && delay > float64(b.Max) {
+		delay = float64(b.Max)
+	}
+	// Without Max, delay soon outgrows a Duration, even to +Inf;
+	// and with no Initial it may be 0 * +Inf, which is NaN.
+	if math.IsNaN(delay) {
+		delay = 0
+	}
+	delay = math.Min(delay, math.MaxInt64)
+	if jitter := math.Min(b.Jitter, 1); jitter > 0 {
+		delay += delay * jitter * (2*rand.Float64() - 1)
+	}
+	if delay >= math.MaxInt64 {
+		return math.MaxInt64, true
+	}
+	return time.Duration(delay), true
+}
+
+// Permanent marks err as not worth retrying; a Fetcher returns it when
+// fetching again cannot succeed, like for a feed that no longer exists.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err}
+}
+
+// IsPermanent reports whether any error in err's chain was marked by Permanent.
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
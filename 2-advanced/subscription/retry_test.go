@@ -0,0 +1,114 @@
+package subscription
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"1-basic/clock"
+)
+
+func TestBackoffGrowth(t *testing.T) {
+	b := Backoff{Initial: time.Second, Max: 10 * time.Second}
+	for i, want := range []time.Duration{
+		time.Second,
+		2 * time.Second,
+		4 * time.Second,
+		8 * time.Second,
+		10 * time.Second, // capped by Max
+		10 * time.Second,
+	} {
+		attempt := i + 1
+		if got, ok := b.Retry(attempt, errBoom); got != want || !ok {
+			t.Errorf("Retry(%d) = %v, %v; want %v, true", attempt, got, ok, want)
+		}
+	}
+	b = Backoff{Initial: time.Second, Multiplier: 3}
+	if got, _ := b.Retry(3, errBoom); got != 9*time.Second {
+		t.Errorf("with Multiplier 3, Retry(3) = %v, want 9s", got)
+	}
+}
+
+func TestBackoffOverflow(t *testing.T) {
+	b := Backoff{Initial: 10 * time.Second}
+	for _, attempt := range []int{31, 100, 10000} {
+		if got, ok := b.Retry(attempt, errBoom); got != math.MaxInt64 || !ok {
+			t.Errorf("Retry(%d) = %v, %v; want %v, true", attempt, got, ok, time.Duration(math.MaxInt64))
+		}
+	}
+	b.Jitter = 1
+	for _, attempt := range []int{31, 100, 10000} {
+		if got, _ := b.Retry(attempt, errBoom); got < 0 {
+			t.Errorf("with Jitter, Retry(%d) = %v, want >= 0", attempt, got)
+		}
+	}
+	if got, _ := (Backoff{Multiplier: 1e300}).Retry(10, errBoom); got != 0 {
+		t.Errorf("with no Initial, Retry = %v, want 0", got)
+	}
+}
+
+func TestBackoffJitterClamped(t *testing.T) {
+	for _, jitter := range []float64{-1, 5} {
+		b := Backoff{Initial: time.Second, Jitter: jitter}
+		for i := 0; i < 100; i++ {
+			if got, _ := b.Retry(1, errBoom); got < 0 || got > 2*time.Second {
+				t.Fatalf("with Jitter %v, Retry(1) = %v, want within [0, 2s]", jitter, got)
+			}
+		}
+	}
+}
+
+func TestBackoffPermanent(t *testing.T) {
+	b := Backoff{Initial: time.Second}
+	for _, err := range []error{
+		Permanent(errBoom),
+		fmt.Errorf("fetching: %w", Permanent(errBoom)),
+	} {
+		if _, ok := b.Retry(1, err); ok {
+			t.Errorf("Retry(%v) = true, want false", err)
+		}
+	}
+	if Permanent(nil) != nil {
+		t.Error("Permanent(nil) != nil")
+	}
+
+	calls := 0
+	f := fetcherFunc(func() ([]Item, time.Time, error) {
+		calls++
+		return nil, time.Time{}, Permanent(errBoom)
+	})
+	clk := clock.NewFake(time.Unix(0, 0))
+	s := Subscribe(f, WithClock(clk), WithRetryPolicy(b))
+	if _, ok := clock.Recv(clk, s.Updates(), time.Second); ok {
+		t.Fatal("Updates delivered an item")
+	}
+	if err := s.Err(); !errors.Is(err, errBoom) || !IsPermanent(err) {
+		t.Errorf("Err = %v, want the permanent %v", err, errBoom)
+	}
+	if calls != 1 {
+		t.Errorf("Fetch called %d times, want 1", calls)
+	}
+}
+
+func TestBackoffMaxAttempts(t *testing.T) {
+	calls := 0
+	f := fetcherFunc(func() ([]Item, time.Time, error) {
+		calls++
+		return nil, time.Time{}, errBoom
+	})
+	clk := clock.NewFake(time.Unix(0, 0))
+	s := Subscribe(f, WithClock(clk), WithRetryPolicy(Backoff{Initial: time.Second, MaxAttempts: 3}))
+	if _, ok := clock.Recv(clk, s.Updates(), time.Second); ok {
+		t.Fatal("Updates delivered an item")
+	}
+	err := s.Err()
+	if !errors.Is(err, errBoom) || !strings.Contains(err.Error(), "giving up after 3") {
+		t.Errorf("Err = %v, want giving up after 3 on %v", err, errBoom)
+	}
+	if calls != 3 {
+		t.Errorf("Fetch called %d times, want 3", calls)
+	}
+}
@@ -0,0 +1,230 @@
+package subscription
+
+import (
+	"bufio"
+	"container/list"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"1-basic/clock"
+)
+
+// SeenStore remembers the GUIDs of the items a subscription has delivered,
+// so that items fetched again are not delivered twice.
+// A SeenStore may be shared by several subscriptions.
+type SeenStore interface {
+	// Seen reports whether guid was recorded before, and records it.
+	Seen(guid string) (bool, error)
+	// Has reports whether guid was recorded before, without recording it.
+	Has(guid string) bool
+}
+
+// defaultSeenSize is how many GUIDs a subscription remembers by default.
+const defaultSeenSize = 10000
+
+// MemorySeen is an in-memory SeenStore that forgets the least recently
+// seen GUID once it holds size of them, and any GUID older than ttl.
+type MemorySeen struct {
+	size  int           // 0 means no bound
+	ttl   time.Duration // 0 means GUIDs never expire
+	clock clock.Clock   // ages GUIDs against ttl
+
+	mu    sync.Mutex
+	order *list.List               // of *seenEntry, most recently seen first
+	guids map[string]*list.Element // index into order
+}
+
+type seenEntry struct {
+	guid string
+	at   time.Time // when guid was first recorded
+}
+
+// NewMemorySeen returns a MemorySeen holding at most size GUIDs,
+// each for at most ttl. Zero means no limit.
+func NewMemorySeen(size int, ttl time.Duration) *MemorySeen {
+	return NewMemorySeenClock(size, ttl, clock.Real)
+}
+
+// NewMemorySeenClock is like NewMemorySeen but ages GUIDs by c.
+func NewMemorySeenClock(size int, ttl time.Duration, c clock.Clock) *MemorySeen {
+	return &MemorySeen{
+		size:  size,
+		ttl:   ttl,
+		clock: c,
+		order: list.New(),
+		guids: make(map[string]*list.Element),
+	}
+}
+
+// Seen implements the SeenStore interface. It never fails.
+func (m *MemorySeen) Seen(guid string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.clock.Now()
+	if e, ok := m.guids[guid]; ok {
+		if m.ttl == 0 || now.Sub(e.Value.(*seenEntry).at) < m.ttl {
+			m.order.MoveToFront(e)
+			return true, nil
+		}
+		m.order.Remove(e) // expired; record it afresh below
+	}
+	m.guids[guid] = m.order.PushFront(&seenEntry{guid: guid, at: now})
+	if m.size > 0 && m.order.Len() > m.size {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.guids, oldest.Value.(*seenEntry).guid)
+	}
+	return false, nil
+}
+
+// Has implements the SeenStore interface.
+func (m *MemorySeen) Has(guid string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.guids[guid]
+	return ok && (m.ttl == 0 || m.clock.Now().Sub(e.Value.(*seenEntry).at) < m.ttl)
+}
+
+// Len returns the number of GUIDs m holds.
+func (m *MemorySeen) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
+
+// guidsOldestFirst returns the GUIDs m holds, least recently seen first.
+func (m *MemorySeen) guidsOldestFirst() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	guids := make([]string, 0, m.order.Len())
+	for e := m.order.Back(); e != nil; e = e.Prev() {
+		guids = append(guids, e.Value.(*seenEntry).guid)
+	}
+	return guids
+}
+
+// FileSeen is a SeenStore backed by an append-only log file, one GUID per
+// line, so a restarted process does not deliver the same items again.
+// It keeps the most recent size GUIDs in memory and rewrites the log
+// whenever it grows to twice that, which bounds both memory and disk.
+type FileSeen struct {
+	path string
+	size int
+	mem  *MemorySeen
+
+	mu    sync.Mutex // guards file and lines
+	file  *os.File
+	lines int // GUIDs in the log, including forgotten ones
+}
+
+// OpenFileSeen opens the log at path, creating it if needed,
+// and loads the most recent size GUIDs from it.
+func OpenFileSeen(path string, size int) (*FileSeen, error) {
+	if size <= 0 {
+		size = defaultSeenSize
+	}
+	f := &FileSeen{path: path, size: size, mem: NewMemorySeen(size, 0)}
+	if err := f.load(); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	f.file = file
+	return f, nil
+}
+
+func (f *FileSeen) load() error {
+	file, err := os.Open(f.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if guid := scanner.Text(); guid != "" {
+			f.mem.Seen(guid)
+			f.lines++
+		}
+	}
+	return scanner.Err()
+}
+
+// Seen implements the SeenStore interface.
+// A GUID that could not be written to the log is still recorded in memory.
+func (f *FileSeen) Seen(guid string) (bool, error) {
+	guid = oneLine(guid)
+	seen, _ := f.mem.Seen(guid)
+	if seen {
+		return true, nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, err := f.file.WriteString(guid + "\n"); err != nil {
+		return false, err
+	}
+	f.lines++
+	if f.lines >= 2*f.size {
+		return false, f.compact()
+	}
+	return false, nil
+}
+
+// Has implements the SeenStore interface.
+func (f *FileSeen) Has(guid string) bool {
+	return f.mem.Has(oneLine(guid))
+}
+
+// oneLine returns guid as FileSeen logs it:
+// the log is line-oriented, so a GUID must fit on one line.
+func oneLine(guid string) string {
+	return strings.ReplaceAll(guid, "\n", " ")
+}
+
+// compact rewrites the log with only the GUIDs still held in memory.
+// The new log replaces the old one atomically, so a crash leaves one or the other.
+func (f *FileSeen) compact() error {
+	guids := f.mem.guidsOldestFirst()
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), ".seen-*")
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(tmp)
+	for _, guid := range guids {
+		w.WriteString(guid + "\n")
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	f.file.Close()
+	f.file, f.lines = file, len(guids)
+	return nil
+}
+
+// Close closes the log file.
+func (f *FileSeen) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
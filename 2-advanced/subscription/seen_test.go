@@ -0,0 +1,173 @@
+package subscription_test
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"1-basic/clock"
+	"2-advanced/subscription"
+	"2-advanced/subscription/feedtest"
+)
+
+func TestDuplicatesDeliveredOnce(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	f := &feedtest.Fetcher{Channel: "blog", Interval: time.Second, Duplicates: true, Clock: clk}
+	seen := subscription.NewMemorySeen(100, 0)
+	s := subscription.Subscribe(f, subscription.WithClock(clk), subscription.WithSeenStore(seen))
+
+	const fetches = 20
+	for i := 0; i < fetches; i++ {
+		// Every Fetch returns all items so far; only the new one gets through.
+		item, _ := clock.Recv(clk, s.Updates(), time.Second)
+		if want := fmt.Sprintf("Item %d", i); item.Title != want {
+			t.Fatalf("update %d is %q, want %q", i, item.Title, want)
+		}
+	}
+	s.Close() // the loop records the last update after sending it
+	if n := seen.Len(); n != fetches {
+		t.Errorf("seen store holds %d GUIDs, want %d", n, fetches)
+	}
+}
+
+func TestPendingNotSeen(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	f := &feedtest.Fetcher{Channel: "blog", Interval: time.Second, Clock: clk}
+	seen := subscription.NewMemorySeen(100, 0)
+	s := subscription.Subscribe(f, subscription.WithClock(clk), subscription.WithSeenStore(seen))
+
+	// Fetch a few items without reading them, then read only the first.
+	for i := 0; i < 3; i++ {
+		<-clk.Blocked(1)
+		clk.Advance(time.Second)
+	}
+	item := <-s.Updates()
+	s.Close()
+	if n := seen.Len(); n != 1 {
+		t.Errorf("seen store holds %d GUIDs, want 1", n)
+	}
+	if !seen.Has(item.GUID) {
+		t.Errorf("delivered %q is not recorded", item.Title)
+	}
+}
+
+func TestMemorySeenTTL(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	seen := subscription.NewMemorySeenClock(0, time.Minute, clk)
+	for _, step := range []struct {
+		advance time.Duration
+		want    bool
+	}{
+		{0, false},               // first sighting
+		{59 * time.Second, true}, // still fresh
+		{time.Second, false},     // expired a minute after the first sighting
+		{30 * time.Second, true}, // recorded afresh by the last call
+	} {
+		clk.Advance(step.advance)
+		got, err := seen.Seen("guid")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != step.want {
+			t.Errorf("Seen at %v = %v, want %v", clk.Now().Sub(time.Unix(0, 0)), got, step.want)
+		}
+	}
+}
+
+func TestSeenBounded(t *testing.T) {
+	f := &feedtest.Fetcher{Channel: "blog", Interval: time.Second, Duplicates: true}
+	const size = 10
+	seen := subscription.NewMemorySeen(size, 0)
+	for i := 0; i < 100; i++ {
+		items, _, err := f.Fetch()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, item := range items {
+			seen.Seen(item.GUID)
+		}
+		if n := seen.Len(); n > size {
+			t.Fatalf("after %d fetches the seen store holds %d GUIDs, more than %d", i+1, n, size)
+		}
+	}
+}
+
+func TestFileSeenReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seen")
+	st, err := subscription.OpenFileSeen(path, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, guid := range []string{"a", "b", "c"} {
+		if seen, err := st.Seen(guid); seen || err != nil {
+			t.Fatalf("Seen(%q) = %v, %v before it was recorded", guid, seen, err)
+		}
+	}
+	if err := st.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	st, err = subscription.OpenFileSeen(path, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	for _, guid := range []string{"a", "b", "c"} {
+		if seen, err := st.Seen(guid); !seen || err != nil {
+			t.Errorf("after reopening, Seen(%q) = %v, %v; want true", guid, seen, err)
+		}
+	}
+	if seen, _ := st.Seen("d"); seen {
+		t.Error("after reopening, Seen(\"d\") = true for a new GUID")
+	}
+}
+
+func TestFileSeenCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seen")
+	const size = 5
+	st, err := subscription.OpenFileSeen(path, size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		if _, err := st.Seen(fmt.Sprintf("guid %d", i)); err != nil {
+			t.Fatal(err)
+		}
+		if n := countLines(t, path); n >= 2*size {
+			t.Fatalf("after %d GUIDs the log has %d lines, want fewer than %d", i+1, n, 2*size)
+		}
+	}
+	if err := st.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The compacted log still holds the most recent GUIDs.
+	st, err = subscription.OpenFileSeen(path, size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	for i := 100 - size; i < 100; i++ {
+		if seen, _ := st.Seen(fmt.Sprintf("guid %d", i)); !seen {
+			t.Errorf("guid %d forgotten by compaction", i)
+		}
+	}
+}
+
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	n := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,215 @@
+package subscription
+
+import "time"
+
+// The loop* methods below are the steps the talk takes to build sub.loop,
+// one concern at a time. They are not used, but kept for reading alongside
+// the final version in subscription.go.
+
+// loopCloseOnly is a version of loop that includes only the logic
+// that handles Close.
+func (s *sub) loopCloseOnly() {
+	var err error // set when Fetch fails
+	for {
+		select {
+		// loop handles Close by replying with the Fetch error and exiting.
+		case errc := <-s.closing:
+			errc <- err
+			close(s.updates) // tells receiver we're done
+			return
+		}
+	}
+}
+
+// loopFetchOnly is a version of loop that includes only the logic
+// that calls Fetch.
+func (s *sub) loopFetchOnly() {
+	var pending []Item // appended by fetch; consumed by send
+	var next time.Time // initially January 1, year 0
+	var err error
+	var failures int // consecutive Fetch errors
+	for {
+		var fetchDelay time.Duration // initially 0 (no delay)
+		if now := s.clock.Now(); next.After(now) {
+			fetchDelay = next.Sub(now)
+		}
+		startFetch := s.clock.After(fetchDelay)
+		select {
+		case <-startFetch:
+			var fetched []Item
+			fetched, next, err = s.fetcher.Fetch(s.ctx)
+			if err != nil {
+				failures++
+				var retry bool
+				if next, retry = s.retry(failures, err); !retry {
+					close(s.updates) // s.Err holds the terminal error
+					return
+				}
+				break
+			}
+			failures = 0
+			pending = append(pending, fetched...)
+		}
+	}
+}
+
+// loopSendOnly is a version of loop that includes only the logic
+// for sending items to s.updates.
+func (s *sub) loopSendOnly() {
+	var pending []Item // appended by fetch; consumed by send
+	for {
+		// Enable send only when pending is non-empty.
+		var first Item
+		var updates chan Item
+		if len(pending) > 0 {
+			first = pending[0]
+			updates = s.updates // enable send case
+		}
+		select {
+		case updates <- first:
+			pending = pending[1:]
+		}
+	}
+}
+
+// loopCombined puts the three cases together.
+// All three cases interact via err, next, and pending.
+// No locks, no condition variables, no callbacks.
+func (s *sub) loopCombined() {
+	var pending []Item
+	var next time.Time
+	var err error
+	var failures int // consecutive Fetch errors
+	for {
+		var fetchDelay time.Duration
+		if now := s.clock.Now(); next.After(now) {
+			fetchDelay = next.Sub(now)
+		}
+		startFetch := s.clock.After(fetchDelay)
+
+		var first Item
+		var updates chan Item
+		if len(pending) > 0 {
+			first = pending[0]
+			updates = s.updates
+		}
+
+		select {
+		case errc := <-s.closing:
+			errc <- err
+			close(s.updates)
+			return
+		case <-startFetch:
+			var fetched []Item
+			fetched, next, err = s.fetcher.Fetch(s.ctx)
+			if err != nil {
+				failures++
+				var retry bool
+				if next, retry = s.retry(failures, err); !retry {
+					close(s.updates) // s.Err holds the terminal error
+					return
+				}
+				break
+			}
+			failures = 0
+			pending = append(pending, fetched...)
+		case updates <- first:
+			pending = pending[1:]
+		}
+	}
+}
+
+// loopDeduplicated extends the loopCombined with de-duplicating of fetched items.
+func (s *sub) loopDeduplicated() {
+	var pending []Item
+	var next time.Time
+	var err error
+	var failures int // consecutive Fetch errors
+	for {
+		var fetchDelay time.Duration
+		if now := s.clock.Now(); next.After(now) {
+			fetchDelay = next.Sub(now)
+		}
+		startFetch := s.clock.After(fetchDelay)
+
+		var first Item
+		var updates chan Item
+		if len(pending) > 0 {
+			first = pending[0]
+			updates = s.updates
+		}
+
+		select {
+		case errc := <-s.closing:
+			errc <- err
+			close(s.updates)
+			return
+		case <-startFetch:
+			var fetched []Item
+			fetched, next, err = s.fetcher.Fetch(s.ctx)
+			if err != nil {
+				failures++
+				var retry bool
+				if next, retry = s.retry(failures, err); !retry {
+					close(s.updates) // s.Err holds the terminal error
+					return
+				}
+				break
+			}
+			failures = 0
+			pending, err = s.appendUnseen(pending, fetched)
+		case updates <- first:
+			pending = pending[1:]
+		}
+	}
+}
+
+// loopMaxPending is a loop version that based on loopDeduplicated
+// disabling fetch case when too much pending
+func (s *sub) loopLimitedPending() {
+	const maxPending = 10
+	var pending []Item
+	var next time.Time
+	var err error
+	var failures int // consecutive Fetch errors
+	for {
+		var fetchDelay time.Duration
+		if now := s.clock.Now(); next.After(now) {
+			fetchDelay = next.Sub(now)
+		}
+		var startFetch <-chan time.Time
+		if len(pending) < maxPending {
+			startFetch = s.clock.After(fetchDelay) // enable fetch case
+		}
+
+		var first Item
+		var updates chan Item
+		if len(pending) > 0 {
+			first = pending[0]
+			updates = s.updates
+		}
+
+		select {
+		case errc := <-s.closing:
+			errc <- err
+			close(s.updates)
+			return
+		case <-startFetch:
+			var fetched []Item
+			fetched, next, err = s.fetcher.Fetch(s.ctx)
+			if err != nil {
+				failures++
+				var retry bool
+				if next, retry = s.retry(failures, err); !retry {
+					close(s.updates) // s.Err holds the terminal error
+					return
+				}
+				break
+			}
+			failures = 0
+			pending, err = s.appendUnseen(pending, fetched)
+		case updates <- first:
+			pending = pending[1:]
+		}
+	}
+}
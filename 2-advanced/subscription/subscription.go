@@ -0,0 +1,388 @@
+// Package subscription turns feeds that must be polled into streams of
+// items, following "Advanced Go Concurrency Patterns" (Google I/O 2013).
+//
+// Subscribe runs a Fetcher in a goroutine and delivers its items on a channel;
+// Merge combines several subscriptions into one.
+package subscription
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"1-basic/clock"
+)
+
+// Item is an entry of a feed.
+type Item struct {
+	Title, Channel, GUID string // a subset of RSS fields
+	Link                 string
+	Published            time.Time
+}
+
+// Fetcher fetches Items and returns the time when the next
+// fetch should be attempted. On failure, Fetch returns an error.
+type Fetcher interface {
+	Fetch() (items []Item, next time.Time, err error)
+}
+
+// FetcherContext is a Fetcher whose Fetch can be cancelled through ctx.
+type FetcherContext interface {
+	Fetch(ctx context.Context) (items []Item, next time.Time, err error)
+}
+
+// AdaptFetcher turns f into a FetcherContext.
+// f itself cannot be interrupted, so when ctx is cancelled Fetch returns
+// ctx.Err() right away and leaves f to finish in the background.
+func AdaptFetcher(f Fetcher) FetcherContext {
+	return fetcherAdapter{f}
+}
+
+type fetcherAdapter struct {
+	f Fetcher
+}
+
+func (a fetcherAdapter) String() string {
+	return describe(a.f)
+}
+
+func (a fetcherAdapter) Fetch(ctx context.Context) ([]Item, time.Time, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, time.Time{}, err
+	}
+	type fetchResult struct {
+		fetched []Item
+		next    time.Time
+		err     error
+	}
+	done := make(chan fetchResult, 1) // buffered so the goroutine never blocks
+	go func() {
+		fetched, next, err := a.f.Fetch()
+		done <- fetchResult{fetched, next, err}
+	}()
+	select {
+	case r := <-done:
+		return r.fetched, r.next, r.err
+	case <-ctx.Done():
+		return nil, time.Time{}, ctx.Err()
+	}
+}
+
+// Subscription is a stream of Items.
+type Subscription interface {
+	Updates() <-chan Item  // stream of Items
+	Close() error          // shuts down the stream
+	Done() <-chan struct{} // closed once the stream has shut down
+	Err() error            // the latest Fetch error, or the terminal error once Done
+	Errors() <-chan error  // stream of non-fatal errors; closed once Done
+	Stats() Stats          // counts of items dropped or spilled by the overflow policy
+}
+
+// An Option configures a subscription created by Subscribe or SubscribeContext.
+type Option func(*sub)
+
+// WithSeenStore sets the store that remembers delivered GUIDs, for example
+// a FileSeen shared by all subscriptions so a restart delivers nothing twice.
+// The default remembers the last 10000 GUIDs in memory.
+func WithSeenStore(st SeenStore) Option {
+	return func(s *sub) {
+		s.seen = st
+	}
+}
+
+// WithPending sets how many fetched items a subscription buffers for a slow
+// consumer, and what it does with more. The default is 10 and BlockFetch.
+// max is at least 1; less counts as 1.
+func WithPending(max int, overflow OverflowPolicy) Option {
+	if max < 1 {
+		max = 1 // the item about to be sent lives in the buffer too
+	}
+	return func(s *sub) {
+		s.pending.max = max
+		s.pending.overflow = overflow
+	}
+}
+
+// WithSpillDir sets the directory for the SpillToDisk file.
+// The default is os.TempDir.
+func WithSpillDir(dir string) Option {
+	return func(s *sub) {
+		s.pending.spillDir = dir
+	}
+}
+
+// WithClock sets the clock the subscription schedules fetches by.
+// The default is clock.Real; tests use a clock.Fake.
+func WithClock(c clock.Clock) Option {
+	return func(s *sub) {
+		s.clock = c
+	}
+}
+
+// WithRetryPolicy sets the policy that decides when to fetch again after
+// a failed Fetch. The default is DefaultRetryPolicy.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(s *sub) {
+		s.retryPolicy = p
+	}
+}
+
+// Subscribe converts Fetchers to a stream.
+func Subscribe(fetcher Fetcher, opts ...Option) Subscription {
+	return SubscribeContext(context.Background(), AdaptFetcher(fetcher), opts...)
+}
+
+// SubscribeContext converts a FetcherContext to a stream.
+// The stream shuts down when ctx is cancelled, and a Fetch in flight
+// is cancelled when the stream shuts down.
+func SubscribeContext(ctx context.Context, fetcher FetcherContext, opts ...Option) Subscription {
+	ctx, cancel := context.WithCancel(ctx)
+	s := &sub{
+		fetcher:     fetcher,
+		ctx:         ctx,
+		updates:     make(chan Item),       // for Updates
+		closing:     make(chan chan error), // for Close
+		done:        make(chan struct{}),   // for Done
+		errors:      make(chan error),      // for Errors
+		retryPolicy: DefaultRetryPolicy,
+		clock:       clock.Real,
+		seen:        NewMemorySeen(defaultSeenSize, 0),
+	}
+	s.pending = pendingQueue{max: defaultMaxPending, overflow: BlockFetch, stats: &s.stats}
+	for _, opt := range opts {
+		opt(s)
+	}
+	go func() {
+		defer close(s.done)
+		defer close(s.errors)
+		defer cancel() // cancels a Fetch still in flight
+		s.loop()
+	}()
+	return s
+}
+
+// sub implements the Subscription interface.
+type sub struct {
+	fetcher FetcherContext  // fetches items
+	ctx     context.Context // cancelled when loop returns
+	updates chan Item       // delivers items to the user
+	closing chan chan error // Close communicates with loop via s.closing
+	done    chan struct{}   // closed when loop has returned
+	errors  chan error      // reports errors the loop recovers from
+
+	retryPolicy RetryPolicy  // decides when to fetch again after an error
+	clock       clock.Clock  // schedules fetches
+	seen        SeenStore    // set of delivered item.GUIDs
+	pending     pendingQueue // owned by loop once it runs
+	stats       Stats        // updated atomically by pending
+
+	mu  sync.Mutex // guards err
+	err error      // the latest Fetch error, or the terminal error once loop has returned
+
+	closeOnce sync.Once // makes Close idempotent
+}
+
+// retry records err, the failures-th Fetch error in a row, and returns when
+// to fetch again. It returns false when the retry policy gives up; the loop
+// must then shut down, and Err reports the terminal error.
+func (s *sub) retry(failures int, err error) (next time.Time, ok bool) {
+	delay, ok := s.retryPolicy.Retry(failures, err)
+	if !ok {
+		err = fmt.Errorf("giving up after %d failed fetches: %w", failures, err)
+	}
+	s.setErr(err)
+	return s.clock.Now().Add(delay), ok
+}
+
+// appendUnseen appends the items of fetched that s.seen has not seen to pending.
+// If the store fails, the item is delivered anyway: a duplicate beats a loss.
+func (s *sub) appendUnseen(pending, fetched []Item) ([]Item, error) {
+	var err error
+	for _, item := range fetched {
+		seen, serr := s.seen.Seen(item.GUID)
+		if serr != nil {
+			err = serr
+		}
+		if !seen {
+			pending = append(pending, item)
+		}
+	}
+	return pending, err
+}
+
+func (s *sub) setErr(err error) {
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+}
+
+// Stats implements the Subscription interface.
+func (s *sub) Stats() Stats {
+	return s.stats.load()
+}
+
+// Errors implements the Subscription interface.
+// Only the latest error is kept for a slow reader.
+func (s *sub) Errors() <-chan error {
+	return s.errors
+}
+
+// String describes s by its fetcher, for error messages.
+func (s *sub) String() string {
+	return describe(s.fetcher)
+}
+
+// describe returns f's String method, or else its type.
+func describe(f interface{}) string {
+	if str, ok := f.(fmt.Stringer); ok {
+		return str.String()
+	}
+	return fmt.Sprintf("%T", f)
+}
+
+// Err implements the Subscription interface.
+func (s *sub) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// loop is the final version which runs Fetch asynchronously.
+func (s *sub) loop() {
+	type fetchResult struct {
+		fetched []Item
+		next    time.Time
+		err     error
+	}
+	var fetchDone chan fetchResult // if non-nil, Fetch is running
+
+	pending := &s.pending
+	defer pending.Close()
+	var next time.Time
+	var err error
+	var report error      // to send on s.errors
+	var failures int      // consecutive Fetch errors
+	var timer clock.Timer // stops the wait for next
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	for {
+		if timer != nil {
+			timer.Stop()
+			timer = nil
+		}
+		var startFetch <-chan time.Time
+		// Only BlockFetch stops fetching when pending is full;
+		// the other policies make room as items arrive.
+		if fetchDone == nil && (pending.overflow != BlockFetch || !pending.Full()) {
+			startFetch, timer = s.clock.At(next) // enable fetch case
+		}
+
+		var first Item
+		var updates chan Item
+		if pending.Len() > 0 {
+			first = pending.Front()
+			updates = s.updates
+		}
+
+		var errs chan error
+		if report != nil {
+			errs = s.errors // enable error case
+		}
+
+		select {
+		case <-startFetch:
+			fetchDone = make(chan fetchResult, 1)
+			go func() {
+				fetched, next, err := s.fetcher.Fetch(s.ctx)
+				fetchDone <- fetchResult{fetched, next, err}
+			}()
+		case result := <-fetchDone:
+			fetchDone = nil
+			fetched := result.fetched
+			next, err = result.next, result.err
+			if err != nil {
+				report = err
+				failures++
+				var retry bool
+				if next, retry = s.retry(failures, err); !retry {
+					close(s.updates) // s.Err holds the terminal error
+					return
+				}
+				break
+			}
+			failures = 0
+			for _, item := range fetched {
+				if s.seen.Has(item.GUID) || pending.Has(item.GUID) {
+					continue // delivered already, or about to be
+				}
+				if perr := pending.Push(item); perr != nil {
+					err = perr
+				}
+			}
+			s.setErr(err)
+			if err != nil {
+				report = err
+			}
+		case updates <- first:
+			// Record first only once it is delivered, so that items still
+			// pending when the process stops are delivered after a restart.
+			// If the store fails, first may be delivered again:
+			// a duplicate beats a loss.
+			if _, serr := s.seen.Seen(first.GUID); serr != nil {
+				err, report = serr, serr
+				s.setErr(err)
+			}
+			if perr := pending.Pop(); perr != nil {
+				err, report = perr, perr
+				s.setErr(err)
+			}
+		case errs <- report:
+			report = nil
+
+		case errc := <-s.closing:
+			errc <- err
+			close(s.updates)
+			return
+
+		case <-s.ctx.Done():
+			if err == nil {
+				err = s.ctx.Err()
+			}
+			s.setErr(err) // for Close
+			close(s.updates)
+			return
+		}
+	}
+}
+
+// Updates implements the Subscription interface.
+func (s *sub) Updates() <-chan Item {
+	return s.updates
+}
+
+// Close implements the Subscription interface.
+// Close asks loop to exit and waits for a response.
+// Only the first call returns the Fetch error; later and concurrent calls
+// wait for loop to exit and return nil.
+func (s *sub) Close() (err error) {
+	s.closeOnce.Do(func() {
+		errc := make(chan error)
+		select {
+		case s.closing <- errc:
+			err = <-errc
+		case <-s.done:
+			err = s.Err() // loop returned on its own
+		}
+	})
+	<-s.done
+	return err
+}
+
+// Done implements the Subscription interface.
+func (s *sub) Done() <-chan struct{} {
+	return s.done
+}
@@ -0,0 +1,192 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"1-basic/clock"
+)
+
+var errBoom = errors.New("boom")
+
+// blockingFetcher fails with err on its first Fetch, if err is set, and
+// then blocks every Fetch until its context is cancelled.
+type blockingFetcher struct {
+	err       error
+	started   chan struct{} // receives when a blocking Fetch starts
+	cancelled chan struct{} // receives when a blocking Fetch sees ctx.Done
+
+	mu    sync.Mutex
+	calls int
+}
+
+func newBlockingFetcher(err error) *blockingFetcher {
+	return &blockingFetcher{err: err, started: make(chan struct{}, 10), cancelled: make(chan struct{}, 10)}
+}
+
+func (f *blockingFetcher) Fetch(ctx context.Context) ([]Item, time.Time, error) {
+	f.mu.Lock()
+	f.calls++
+	first := f.calls == 1
+	f.mu.Unlock()
+	if first && f.err != nil {
+		return nil, time.Time{}, f.err
+	}
+	f.started <- struct{}{}
+	<-ctx.Done()
+	f.cancelled <- struct{}{}
+	return nil, time.Time{}, ctx.Err()
+}
+
+// noRetry retries after an hour, which a Fake clock never reaches.
+type noRetry struct{}
+
+func (noRetry) Retry(int, error) (time.Duration, bool) { return time.Hour, true }
+
+func waitFor(t *testing.T, c <-chan struct{}, what string) {
+	t.Helper()
+	select {
+	case <-c:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %s", what)
+	}
+}
+
+func TestCloseDuringFetch(t *testing.T) {
+	f := newBlockingFetcher(nil)
+	s := SubscribeContext(context.Background(), f, WithClock(clock.NewFake(time.Unix(0, 0))))
+	waitFor(t, f.started, "Fetch to start")
+
+	if err := s.Close(); err != nil {
+		t.Errorf("Close = %v, want nil", err)
+	}
+	waitFor(t, f.cancelled, "the Fetch in flight to be cancelled")
+	waitFor(t, s.Done(), "Done")
+	if _, ok := <-s.Updates(); ok {
+		t.Error("Updates still open after Close")
+	}
+	if _, ok := <-s.Errors(); ok {
+		t.Error("Errors still open after Close")
+	}
+}
+
+func TestCloseDuringAdaptedFetch(t *testing.T) {
+	unblock := make(chan struct{})
+	defer close(unblock)
+	started := make(chan struct{}, 1)
+	f := fetcherFunc(func() ([]Item, time.Time, error) {
+		started <- struct{}{}
+		<-unblock // cannot be interrupted
+		return nil, time.Time{}, nil
+	})
+	s := Subscribe(f, WithClock(clock.NewFake(time.Unix(0, 0))))
+	waitFor(t, started, "Fetch to start")
+
+	closed := make(chan struct{})
+	go func() {
+		s.Close()
+		close(closed)
+	}()
+	waitFor(t, closed, "Close to return while Fetch is stuck")
+}
+
+type fetcherFunc func() ([]Item, time.Time, error)
+
+func (f fetcherFunc) Fetch() ([]Item, time.Time, error) { return f() }
+
+func TestCloseReturnsErrorOnce(t *testing.T) {
+	f := newBlockingFetcher(errBoom)
+	s := SubscribeContext(context.Background(), f,
+		WithClock(clock.NewFake(time.Unix(0, 0))), WithRetryPolicy(noRetry{}))
+	if err := <-s.Errors(); !errors.Is(err, errBoom) {
+		t.Fatalf("Errors delivered %v, want %v", err, errBoom)
+	}
+
+	if err := s.Close(); !errors.Is(err, errBoom) {
+		t.Errorf("first Close = %v, want %v", err, errBoom)
+	}
+	if err := s.Close(); err != nil {
+		t.Errorf("second Close = %v, want nil", err)
+	}
+	waitFor(t, s.Done(), "Done")
+}
+
+func TestConcurrentClose(t *testing.T) {
+	f := newBlockingFetcher(errBoom)
+	s := SubscribeContext(context.Background(), f,
+		WithClock(clock.NewFake(time.Unix(0, 0))), WithRetryPolicy(noRetry{}))
+	<-s.Errors()
+
+	const closers = 10
+	errs := make(chan error, closers)
+	var wg sync.WaitGroup
+	for i := 0; i < closers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- s.Close()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	n := 0
+	for err := range errs {
+		if err != nil {
+			if !errors.Is(err, errBoom) {
+				t.Errorf("Close = %v, want %v or nil", err, errBoom)
+			}
+			n++
+		}
+	}
+	if n != 1 {
+		t.Errorf("%d of %d concurrent Closes returned the error, want exactly 1", n, closers)
+	}
+	waitFor(t, s.Done(), "Done")
+}
+
+func TestContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	f := newBlockingFetcher(nil)
+	s := SubscribeContext(ctx, f, WithClock(clock.NewFake(time.Unix(0, 0))))
+	waitFor(t, f.started, "Fetch to start")
+
+	cancel()
+	waitFor(t, s.Done(), "Done after cancel")
+	waitFor(t, f.cancelled, "the Fetch in flight to be cancelled")
+	if err := s.Err(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Err = %v, want %v", err, context.Canceled)
+	}
+	if err := s.Close(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Close after cancel = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestPopUnreadableSpill(t *testing.T) {
+	q := &pendingQueue{max: 1, overflow: SpillToDisk, spillDir: t.TempDir(), stats: new(Stats)}
+	defer q.Close()
+	for _, guid := range []string{"a", "b", "c"} {
+		if err := q.Push(Item{GUID: guid}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Corrupt b, the first spilled item.
+	if _, err := q.spill.f.WriteAt([]byte("x"), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := q.Pop(); err == nil {
+		t.Error("Pop of an unreadable spill succeeded")
+	}
+	if q.Len() != 1 || q.Front().GUID != "c" {
+		t.Fatalf("after Pop, Len = %d, want 1 with c in front", q.Len())
+	}
+	if q.Has("b") {
+		t.Error("the lost b is still pending")
+	}
+	if got := q.stats.load(); got != (Stats{Dropped: 1, Spilled: 2}) {
+		t.Errorf("Stats = %+v, want 1 dropped, 2 spilled", got)
+	}
+}